@@ -8,17 +8,19 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"os"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
 const (
-	DefaultHost       = "https://scrapbox.io"
-	DefaultExpiration = 60 * 60 // time.Second
-	DefaultUserAgent  = "ScrapboxGoClient/0.3.0"
+	DefaultHost               = "https://scrapbox.io"
+	DefaultExpiration         = 60 * 60 // time.Second
+	DefaultNegativeExpiration = 5 * 60  // time.Second
+	DefaultUserAgent          = "ScrapboxGoClient/0.3.0"
 )
 
 type Client struct {
@@ -28,6 +30,27 @@ type Client struct {
 	Token      string
 	Expiration time.Duration
 	UserAgent  string
+
+	// NegativeExpiration is the expiration of the cached 404, shorter than Expiration
+	// since missing pages are created at any time. Zero disables caching 404.
+	NegativeExpiration time.Duration
+
+	Retry       RetryPolicy
+	RateLimiter *RateLimiter
+
+	// Cache stores responses for Expiration. A nil Cache disables caching.
+	Cache Cache
+	// Offline serves responses from Cache regardless of their age, never requesting the api.
+	Offline bool
+	// CachePolicy allows expired entries of Cache to be served.
+	CachePolicy CachePolicy
+	// Warn is called with the message when an expired entry is served on error,
+	// or when a revalidation in background fails.
+	Warn func(message string)
+
+	mu           sync.Mutex
+	wg           sync.WaitGroup
+	revalidating map[string]bool
 }
 
 func NewClient(url *url.URL, token string, expiration int, userAgent string) (*Client, error) {
@@ -37,153 +60,242 @@ func NewClient(url *url.URL, token string, expiration int, userAgent string) (*C
 		Token:      token,
 		Expiration: time.Duration(expiration) * time.Second,
 		UserAgent:  userAgent,
+		Retry:      NewRetryPolicy(DefaultRetries, DefaultRetryWait),
+		Cache:      NewFileCache(DefaultCacheDir()),
+
+		NegativeExpiration: DefaultNegativeExpiration * time.Second,
 	}, nil
 }
 
 func (c *Client) ExecQuery(ctx context.Context, project string, tags []string, skip, limit int) (*QueryResult, error) {
+	return c.Query(ctx, project, tags, QueryOptions{Skip: skip, Limit: limit})
+}
+
+func (c *Client) Query(ctx context.Context, project string, tags []string, opts QueryOptions) (*QueryResult, error) {
 
 	var (
-		count int
-		pages []string
+		pages     []string
+		summaries []PageSummary
+	)
+
+	it := c.Pages(project, tags, opts)
+	for it.Next(ctx) {
+		p := it.Page()
+		pages = append(pages, p.Title)
+		summaries = append(summaries, p)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
 
-		v interface{}
+	return &QueryResult{
+		Count:     it.Count(),
+		Pages:     pages,
+		Summaries: summaries,
+	}, nil
+}
+
+func (c *Client) fetchPageList(ctx context.Context, project string, tags []string, skip, limit int, sort string) (*PageList, error) {
+
+	var (
+		v PageList
 	)
 
-	host := (*c.URL).Host
-	expiration := c.Expiration
-	if haveGoodQueryResultFile(host, project, tags, skip, limit, expiration) {
-		res, err := openQueryResultFile(host, project, tags, skip, limit)
-		if err != nil {
-			return nil, err
-		}
-		if err := c.decodeFromFile(res, &v); err != nil {
-			return nil, err
-		}
-	} else {
-		queryPath := buildQueryPath(project, tags, skip, limit)
-		req, err := c.newRequest(ctx, "GET", queryPath, nil)
-		if err != nil {
-			return nil, err
-		}
+	key := QueryCacheKey((*c.URL).Host, project, tags, skip, limit, sort)
+	queryPath := buildQueryPath(project, tags, skip, limit, sort)
+	if err := c.fetch(ctx, key, queryPath, &v); err != nil {
+		return nil, err
+	}
 
-		res, err := c.HTTPClient.Do(req)
-		if err != nil {
-			return nil, err
-		}
+	return &v, nil
+}
 
-		if res.StatusCode != 200 {
-			return nil, errors.New(fmt.Sprintf("http status is %q", res.Status))
-		}
+func (c *Client) GetPage(ctx context.Context, project, page string) (*Page, error) {
 
-		resp, err := createQueryResultFile(host, project, tags, skip, limit)
-		if err != nil {
-			return nil, err
-		}
+	var (
+		v PageDetail
+	)
 
-		if err := c.decodeBody(res, &v, resp); err != nil {
-			return nil, err
-		}
+	key := PageCacheKey((*c.URL).Host, project, page)
+	pagePath := buildPagePath(project, page)
+	if err := c.fetch(ctx, key, pagePath, &v); err != nil {
+		return nil, err
 	}
 
-	for _, p := range v.(interface{}).(map[string]interface{})["pages"].([]interface{}) {
-		if len(tags) > 0 {
-			for _, s := range p.(map[string]interface{})["snipet"].([]interface{}) {
-				all := true
-				for _, t := range tags {
-					all = all &&
-						(strings.Contains(strings.ToLower(s.(string)), fmt.Sprintf("<b>%s</b>", strings.ToLower(t))) ||
-							strings.Contains(strings.ToLower(p.(map[string]interface{})["title"].(interface{}).(string)), strings.ToLower(t)))
-				}
-				if all {
-					pages = append(pages, p.(map[string]interface{})["title"].(interface{}).(string))
-					break
-				}
-			}
-		} else {
-			pages = append(pages, p.(map[string]interface{})["title"].(interface{}).(string))
+	return newPage(&v), nil
+}
+
+type response interface {
+	validate() error
+}
+
+// fetch decodes the response of the api path into v. The cached entry of the key is used
+// instead of requesting while it is not expired, and revalidated with its validators otherwise.
+// Expired entries may also be used as CachePolicy allows.
+func (c *Client) fetch(ctx context.Context, key, path string, v response) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entry, err := c.cache().Get(key)
+	if err != nil {
+		entry = nil
+	}
+	if entry != nil && entry.StatusCode != 0 {
+		if c.Offline || !entry.Expired(c.NegativeExpiration) {
+			status := fmt.Sprintf("%d %s", entry.StatusCode, http.StatusText(entry.StatusCode))
+			return newStatusError(entry.StatusCode, status, c.buildURL(path), entry.Body)
 		}
+		entry = nil
+	}
+	if c.Offline {
+		if entry == nil {
+			return &NotCachedError{Key: key}
+		}
+		return decodeResponse(entry.Body, v)
+	}
+	if entry != nil && !entry.Expired(c.Expiration) {
+		if err := decodeResponse(entry.Body, v); err == nil {
+			return nil
+		}
+		entry = nil
+	}
+	if entry != nil && c.CachePolicy.serveWhileRevalidate(entry, c.Expiration) {
+		if err := decodeResponse(entry.Body, v); err == nil {
+			c.revalidate(key, path, entry, v)
+			return nil
+		}
+		entry = nil
 	}
 
-	count = int(v.(interface{}).(map[string]interface{})["count"].(float64))
-	if count > limit+skip || count == limit {
-		q, err := c.ExecQuery(context.Background(), project, tags, skip+limit, limit)
-		if err != nil {
-			return nil, err
+	err = c.request(ctx, key, path, entry, v)
+	if err != nil && entry != nil && c.CachePolicy.serveIfError(entry, c.Expiration, err) {
+		if decodeResponse(entry.Body, v) == nil {
+			c.warn(fmt.Sprintf("serving the expired cache stored at %s. key: %s, cause: %s", entry.StoredAt.Format(time.RFC3339), key, err))
+			return nil
 		}
-		pages = append(pages, q.Pages...)
 	}
 
-	return &QueryResult{
-		Count: count,
-		Pages: pages,
-	}, nil
+	return err
 }
 
-func (c *Client) GetPage(ctx context.Context, project, page string) (*Page, error) {
+// request decodes the response of the api path into v, and stores it as the entry of the key.
+// The expired entry, if any, is revalidated with its validators.
+func (c *Client) request(ctx context.Context, key, path string, entry *CacheEntry, v response) error {
 
-	var (
-		v interface{}
-	)
+	cache := c.cache()
 
-	host := (*c.URL).Host
-	expiration := c.Expiration
-	if haveGoodPageFile(host, project, page, expiration) {
-		res, err := openPageFile(host, project, page)
-		if err != nil {
-			return nil, err
-		}
-		if err := c.decodeFromFile(res, &v); err != nil {
-			return nil, err
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		if len(entry.ETag) != 0 {
+			req.Header.Set("If-None-Match", entry.ETag)
 		}
-	} else {
-		pagePath := buildPagePath(project, page)
-		req, err := c.newRequest(ctx, "GET", pagePath, nil)
-		if err != nil {
-			return nil, err
+		if len(entry.LastModified) != 0 {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
 		}
+	}
 
-		res, err := c.HTTPClient.Do(req)
-		if err != nil {
-			return nil, err
-		}
+	res, err := c.do(req)
+	if err != nil {
+		return err
+	}
 
-		if res.StatusCode != 200 {
-			return nil, errors.New(fmt.Sprintf("http status is %q", res.Status))
+	if res.StatusCode == http.StatusNotModified && entry != nil {
+		res.Body.Close()
+		if err := decodeResponse(entry.Body, v); err != nil {
+			cache.Invalidate(key)
+			return err
 		}
+		entry.StoredAt = time.Now()
+		return cache.Put(key, entry)
+	}
 
-		resp, err := createPageFile(host, project, page)
-		if err != nil {
-			return nil, err
+	if res.StatusCode != 200 {
+		err := newHTTPError(res)
+		// the cached page, if any, is deleted
+		if e, ok := err.(*NotFoundError); ok {
+			if c.NegativeExpiration > 0 {
+				cache.Put(key, &CacheEntry{Body: e.Body, StoredAt: time.Now(), StatusCode: e.StatusCode})
+			} else {
+				cache.Invalidate(key)
+			}
 		}
+		return err
+	}
 
-		if err := c.decodeBody(res, &v, resp); err != nil {
-			return nil, err
-		}
+	body, err := readBody(res)
+	if err != nil {
+		return err
+	}
+
+	if err := decodeResponse(body, v); err != nil {
+		return err
 	}
 
-	title := v.(interface{}).(map[string]interface{})["title"].(string)
-	lines := make([]string, len(v.(interface{}).(map[string]interface{})["lines"].([]interface{})))
-	for i, l := range v.(interface{}).(map[string]interface{})["lines"].([]interface{}) {
-		lines[i] = l.(map[string]interface{})["text"].(interface{}).(string)
+	return cache.Put(key, &CacheEntry{
+		Body:         body,
+		StoredAt:     time.Now(),
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	})
+}
+
+// revalidate requests the api path in background to refresh the expired entry of the key.
+// Revalidations of the same key are not run at once.
+func (c *Client) revalidate(key, path string, entry *CacheEntry, v response) {
+
+	c.mu.Lock()
+	if c.revalidating == nil {
+		c.revalidating = map[string]bool{}
 	}
-	links := make([]string, len(v.(interface{}).(map[string]interface{})["links"].([]interface{})))
-	for i, l := range v.(interface{}).(map[string]interface{})["links"].([]interface{}) {
-		links[i] = l.(string)
+	if c.revalidating[key] {
+		c.mu.Unlock()
+		return
 	}
+	c.revalidating[key] = true
+	c.wg.Add(1)
+	c.mu.Unlock()
+
+	fresh := reflect.New(reflect.TypeOf(v).Elem()).Interface().(response)
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.revalidating, key)
+			c.mu.Unlock()
+			c.wg.Done()
+		}()
+
+		if err := c.request(context.Background(), key, path, entry, fresh); err != nil {
+			c.warn(fmt.Sprintf("failed to revalidate the expired cache. key: %s, cause: %s", key, err))
+		}
+	}()
+}
 
-	return &Page{
-		Title: title,
-		Lines: lines,
-		Links: links,
-	}, nil
+// Wait blocks until the revalidations running in background finish.
+func (c *Client) Wait() {
+	c.wg.Wait()
 }
 
-func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+func (c *Client) warn(message string) {
+	if c.Warn != nil {
+		c.Warn(message)
+	}
+}
 
-	baseURL := *c.URL
-	u := fmt.Sprintf("%s/%s", baseURL.String(), path)
+func (c *Client) cache() Cache {
+	if c.Cache == nil {
+		return NopCache{}
+	}
+	return c.Cache
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
 
-	req, err := http.NewRequest(method, u, body)
+	req, err := http.NewRequest(method, c.buildURL(path), body)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to instantiate http request")
 	}
@@ -192,6 +304,7 @@ func (c *Client) newRequest(ctx context.Context, method, path string, body io.Re
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Accept-Encoding", "gzip")
 	if len(c.Token) != 0 {
 		req.Header.Set("Cookie", "connect.sid="+c.Token)
 	}
@@ -199,26 +312,76 @@ func (c *Client) newRequest(ctx context.Context, method, path string, body io.Re
 	return req, nil
 }
 
-func (c *Client) decodeBody(resp *http.Response, out interface{}, f *os.File) error {
-	defer resp.Body.Close()
-	if f != nil {
-		resp.Body = ioutil.NopCloser(io.TeeReader(resp.Body, f))
-		defer f.Close()
+func (c *Client) buildURL(path string) string {
+	baseURL := *c.URL
+	return fmt.Sprintf("%s/%s", baseURL.String(), path)
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		if c.RateLimiter != nil {
+			if err := c.RateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		res, err := c.HTTPClient.Do(req)
+		if err == nil {
+			if err = decodeContentEncoding(res); err != nil {
+				res = nil
+			}
+		}
+		if ctx.Err() != nil {
+			if res != nil {
+				res.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+		if err == nil && res.StatusCode == 200 {
+			return res, nil
+		}
+		if !c.Retry.shouldRetry(attempt, res, err) {
+			return res, err
+		}
+
+		wait := c.Retry.delay(attempt, res)
+		if res != nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
 	}
-	decoder := json.NewDecoder(resp.Body)
-	return decoder.Decode(out)
 }
 
-func (c *Client) decodeFromFile(resp *os.File, out interface{}) error {
-	defer resp.Close()
-	decoder := json.NewDecoder(resp)
-	return decoder.Decode(out)
+func readBody(res *http.Response) ([]byte, error) {
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response")
+	}
+	return body, nil
+}
+
+func decodeResponse(body []byte, v response) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return errors.Wrap(err, "failed to decode response")
+	}
+	return v.validate()
 }
 
 func GetURL(host, project, page string) string {
 	return fmt.Sprintf("%s/%s/%s", host, project, encodeURIComponent(page))
 }
 
+func GetIconURL(host, project, page string) string {
+	return fmt.Sprintf("%s/api/pages/%s/%s/icon", host, project, encodeURIComponent(page))
+}
+
 func encodeURIComponent(component string) string {
 	regularEscaped := url.QueryEscape(component)
 	rParenUnescaped := strings.Replace(regularEscaped, "%28", "(", -1)
@@ -227,8 +390,8 @@ func encodeURIComponent(component string) string {
 	return plusEscaped
 }
 
-func buildQueryPath(project string, tags []string, skip, limit int) string {
-	params := fmt.Sprintf("skip=%d&sort=updated&limit=%d&q=%s", skip, limit, encodeURIComponent(strings.Join(tags, " ")))
+func buildQueryPath(project string, tags []string, skip, limit int, sort string) string {
+	params := fmt.Sprintf("skip=%d&sort=%s&limit=%d&q=%s", skip, sort, limit, encodeURIComponent(strings.Join(tags, " ")))
 	if len(tags) == 0 {
 		return fmt.Sprintf("api/pages/%s?%s", project, params)
 	} else {
@@ -0,0 +1,291 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetPage__revalidate(t *testing.T) {
+
+	requests, notModified := 0, 0
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		json.NewEncoder(w).Encode(PageDetail{
+			PageSummary: PageSummary{Title: "title"},
+			Lines:       []PageLine{{Text: "title"}, {Text: "body"}},
+		})
+	}))
+	defer testAPIServer.Close()
+
+	u, _ := url.Parse(testAPIServer.URL)
+	c, _ := NewClient(u, "", 0, DefaultUserAgent)
+	c.Cache = NewMemoryCache()
+
+	for i := 0; i < 2; i++ {
+		page, err := c.GetPage(context.Background(), "go-scrapbox", "title")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Lines) != 2 || page.Lines[1] != "body" || page.LineDetails[1].Text != "body" {
+			t.Fatalf("Got %v", page.Lines)
+		}
+	}
+
+	if requests != 2 || notModified != 1 {
+		t.Fatalf("Requests are %d and not modified are %d, but want %d and %d", requests, notModified, 2, 1)
+	}
+
+	entry, err := c.Cache.Get(PageCacheKey(u.Host, "go-scrapbox", "title"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Now().Sub(entry.StoredAt) > time.Second {
+		t.Fatalf("Stored at %v, but want refreshed", entry.StoredAt)
+	}
+}
+
+func RunPageAPIServer(status int, text string, requests *int) *httptest.Server {
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		json.NewEncoder(w).Encode(PageDetail{
+			PageSummary: PageSummary{Title: "title"},
+			Lines:       []PageLine{{Text: "title"}, {Text: text}},
+		})
+	}))
+}
+
+func newStaleEntry(t *testing.T, text string, age time.Duration) *CacheEntry {
+
+	body, err := json.Marshal(PageDetail{
+		PageSummary: PageSummary{Title: "title"},
+		Lines:       []PageLine{{Text: "title"}, {Text: text}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &CacheEntry{Body: body, StoredAt: time.Now().Add(-age)}
+}
+
+func TestGetPage__stale_if_error(t *testing.T) {
+
+	for _, fixture := range []struct {
+		status int
+		stale  bool
+	}{
+		{http.StatusServiceUnavailable, true},
+		{http.StatusNotFound, false},
+	} {
+		requests := 0
+		testAPIServer := RunPageAPIServer(fixture.status, "fresh", &requests)
+
+		u, _ := url.Parse(testAPIServer.URL)
+		c, _ := NewClient(u, "", 60*60, DefaultUserAgent)
+		c.Retry = NewRetryPolicy(0, 0)
+		c.Cache = NewMemoryCache()
+		c.Cache.Put(PageCacheKey(u.Host, "go-scrapbox", "title"), newStaleEntry(t, "stale", 2*time.Hour))
+		c.CachePolicy.StaleIfError = 2 * time.Hour
+
+		var warnings []string
+		c.Warn = func(message string) {
+			warnings = append(warnings, message)
+		}
+
+		page, err := c.GetPage(context.Background(), "go-scrapbox", "title")
+		testAPIServer.Close()
+
+		if !fixture.stale {
+			if err == nil || len(warnings) != 0 {
+				t.Fatalf("%d: Got %v and %v, but want the error", fixture.status, page, warnings)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: %s", fixture.status, err)
+		}
+		if page.Lines[1] != "stale" || len(warnings) != 1 {
+			t.Fatalf("%d: Got %v and %v, but want the stale page with a warning", fixture.status, page.Lines, warnings)
+		}
+	}
+}
+
+func TestGetPage__stale_while_revalidate(t *testing.T) {
+
+	requests := 0
+	testAPIServer := RunPageAPIServer(http.StatusOK, "fresh", &requests)
+	defer testAPIServer.Close()
+
+	u, _ := url.Parse(testAPIServer.URL)
+	c, _ := NewClient(u, "", 60*60, DefaultUserAgent)
+	c.Cache = NewMemoryCache()
+	key := PageCacheKey(u.Host, "go-scrapbox", "title")
+	c.Cache.Put(key, newStaleEntry(t, "stale", 2*time.Hour))
+	c.CachePolicy.StaleWhileRevalidate = 2 * time.Hour
+
+	page, err := c.GetPage(context.Background(), "go-scrapbox", "title")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Lines[1] != "stale" {
+		t.Fatalf("Got %v, but want the stale page", page.Lines)
+	}
+
+	c.Wait()
+
+	page, err = c.GetPage(context.Background(), "go-scrapbox", "title")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Lines[1] != "fresh" || requests != 1 {
+		t.Fatalf("Got %v after %d requests, but want the revalidated page", page.Lines, requests)
+	}
+}
+
+func TestGetPage__gzip_response(t *testing.T) {
+
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		json.NewEncoder(gw).Encode(PageDetail{
+			PageSummary: PageSummary{Title: "title"},
+			Lines:       []PageLine{{Text: "title"}, {Text: "gzip"}},
+		})
+		gw.Close()
+	}))
+	defer testAPIServer.Close()
+
+	u, _ := url.Parse(testAPIServer.URL)
+	c, _ := NewClient(u, "", 0, DefaultUserAgent)
+	c.Cache = NewMemoryCache()
+
+	page, err := c.GetPage(context.Background(), "go-scrapbox", "title")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Lines[1] != "gzip" {
+		t.Fatalf("Got %v", page.Lines)
+	}
+}
+
+func TestGetPage__malformed_response(t *testing.T) {
+
+	for _, fixture := range []struct {
+		body string
+		want string
+	}{
+		{`{"title": "title", "lines": [`, "failed to decode response"},
+		{`{"name": "NotFoundError", "message": "Page not found."}`, "malformed page response: missing title"},
+	} {
+		testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(fixture.body))
+		}))
+
+		u, _ := url.Parse(testAPIServer.URL)
+		c, _ := NewClient(u, "", 60*60, DefaultUserAgent)
+		c.Cache = NewMemoryCache()
+
+		page, err := c.GetPage(context.Background(), "go-scrapbox", "title")
+		testAPIServer.Close()
+
+		if err == nil || !strings.Contains(err.Error(), fixture.want) {
+			t.Fatalf("%s: Got %v and %v, but want %q", fixture.body, page, err, fixture.want)
+		}
+		if _, err := c.Cache.Get(PageCacheKey(u.Host, "go-scrapbox", "title")); err != ErrCacheMiss {
+			t.Fatalf("%s: Got %v, but want the malformed response not cached", fixture.body, err)
+		}
+	}
+}
+
+func TestGetPage__typed_errors(t *testing.T) {
+
+	for _, fixture := range []struct {
+		status int
+		check  func(err error) bool
+	}{
+		{http.StatusUnauthorized, func(err error) bool { _, ok := err.(*UnauthorizedError); return ok }},
+		{http.StatusNotFound, func(err error) bool { _, ok := err.(*NotFoundError); return ok }},
+		{http.StatusInternalServerError, func(err error) bool { _, ok := err.(*ServerError); return ok }},
+	} {
+		requests := 0
+		testAPIServer := RunPageAPIServer(fixture.status, "", &requests)
+
+		u, _ := url.Parse(testAPIServer.URL)
+		c, _ := NewClient(u, "", 60*60, DefaultUserAgent)
+		c.Retry = NewRetryPolicy(0, 0)
+		c.Cache = NopCache{}
+
+		_, err := c.GetPage(context.Background(), "go-scrapbox", "title")
+		testAPIServer.Close()
+
+		if !fixture.check(err) {
+			t.Fatalf("%d: Got %T %v", fixture.status, err, err)
+		}
+		if !strings.Contains(err.Error(), strconv.Itoa(fixture.status)) {
+			t.Fatalf("%d: Got %v, but want the status in the message", fixture.status, err)
+		}
+		if requests != 1 {
+			t.Fatalf("%d: Requests are %d, but want %d", fixture.status, requests, 1)
+		}
+	}
+}
+
+func TestGetPage__retry_after(t *testing.T) {
+
+	requests := 0
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(PageDetail{
+			PageSummary: PageSummary{Title: "title"},
+			Lines:       []PageLine{{Text: "title"}, {Text: "retried"}},
+		})
+	}))
+	defer testAPIServer.Close()
+
+	u, _ := url.Parse(testAPIServer.URL)
+	c, _ := NewClient(u, "", 0, DefaultUserAgent)
+	c.Retry = NewRetryPolicy(2, 0)
+	c.Cache = NopCache{}
+
+	start := time.Now()
+	page, err := c.GetPage(context.Background(), "go-scrapbox", "title")
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Lines[1] != "retried" {
+		t.Fatalf("Got %v", page.Lines)
+	}
+	if requests != 2 {
+		t.Fatalf("Requests are %d, but want %d", requests, 2)
+	}
+	if elapsed < time.Second {
+		t.Fatalf("Waited %v, but want at least the Retry-After of %v", elapsed, time.Second)
+	}
+}
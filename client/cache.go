@@ -1,124 +1,438 @@
 package client
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/url"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 )
 
+// ErrCacheMiss is returned by Cache.Get when no entry is stored with the key.
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache stores raw api responses. Keys are built by PageCacheKey and QueryCacheKey,
+// such as "page/scrapbox.io/project/title". Implementations must be safe for concurrent use.
+//
+// Cache takes no TTL and must not expire entries by itself. The client decides whether an entry
+// is fresh by Client.Expiration and Client.NegativeExpiration, and still uses the expired one
+// to revalidate it, to serve it by CachePolicy and to serve it offline. Entries are removed
+// only by Invalidate, which the "cache purge" and "cache gc" sub commands call for FileCache.
+type Cache interface {
+	// Get returns the entry stored with the key regardless of its age,
+	// or ErrCacheMiss if there is none.
+	Get(key string) (*CacheEntry, error)
+	// Put stores the entry with the key, replacing the existing one.
+	Put(key string, entry *CacheEntry) error
+	// Invalidate removes the entry stored with the key, if any.
+	Invalidate(key string) error
+}
+
+type CacheEntry struct {
+	Body     []byte
+	StoredAt time.Time
+
+	// ETag and LastModified are the validators of the response, used to revalidate expired entries.
+	ETag         string
+	LastModified string
+
+	// StatusCode is zero for the successful response. 404 is stored as well to cache missing pages.
+	StatusCode int
+}
+
+// cacheMeta is stored in the first line of the file of FileCache, indexing the file by the key.
+// It is also the sidecar file suffixed with ".meta" in the layout before hashed filenames.
+type cacheMeta struct {
+	Key          string `json:"key"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	StatusCode   int    `json:"statusCode,omitempty"`
+}
+
 const (
-	EnvHome = "SCRAPBOX_HOME"
+	entriesDir     = "entries"
+	metaFileSuffix = ".meta"
+	lockFileSuffix = ".lock"
+	tempFilePrefix = ".tmp-"
 )
 
-func createQueryResultFile(host, project string, tags []string, skip, limit int) (*os.File, error) {
+// Expired reports whether the entry is older than ttl.
+func (e *CacheEntry) Expired(ttl time.Duration) bool {
+	return time.Now().Sub(e.StoredAt) > ttl
+}
+
+// FileCache is the default Cache, which stores entries as files under Dir.
+// The file is named by the hash of the key, so that any title is safe on any file system.
+// The modification time of a file is the time the entry is stored at. The key, the validators
+// and the status code are stored in the first line of the file followed by the body, so that
+// the entry is replaced at once.
+type FileCache struct {
+	Dir string
+	// Compression is applied to the files stored from now on, one of Compressions.
+	// Files are read whatever compression they are stored with.
+	Compression string
 
-	baseDir := path.Join(getScrapboxHomeDir(), "query", trimPortFromHost(host), project, path.Join(tags...))
-	if err := os.MkdirAll(baseDir, os.ModePerm); err != nil {
-		return nil, errors.Wrap(err, "failed to make query cache directory")
+	migrateOnce sync.Once
+	unmigrated  []string
+}
+
+func NewFileCache(dir string) *FileCache {
+	return &FileCache{Dir: dir}
+}
+
+func (c *FileCache) Get(key string) (*CacheEntry, error) {
+
+	c.migrate()
+
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		return nil, ErrCacheMiss
+	}
+	defer f.Close()
+
+	// the time and the content are of the same file even if replaced meanwhile
+	fs, err := f.Stat()
+	if err != nil || fs.IsDir() {
+		return nil, ErrCacheMiss
+	}
+	r := bufio.NewReader(f)
+	meta, err := readCacheHeader(r)
+	if err != nil || meta.Key != key {
+		return nil, ErrCacheMiss
 	}
-	queryResultFilePath := path.Join(baseDir, EncodeFilename(fmt.Sprintf("%d-%d", skip, limit)))
-	queryResultFile, err := os.Create(queryResultFilePath)
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cache file")
+	}
+	body, err := decompress(data)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create query cache file")
+		return nil, errors.Wrap(err, "failed to decompress cache file")
 	}
 
-	return queryResultFile, nil
+	return &CacheEntry{
+		Body:         body,
+		StoredAt:     fs.ModTime(),
+		ETag:         meta.ETag,
+		LastModified: meta.LastModified,
+		StatusCode:   meta.StatusCode,
+	}, nil
 }
 
-func haveGoodQueryResultFile(host, project string, tags []string, skip, limit int, expiration time.Duration) bool {
+func (c *FileCache) Put(key string, entry *CacheEntry) error {
+	c.migrate()
+	return c.put(key, entry)
+}
+
+func (c *FileCache) put(key string, entry *CacheEntry) error {
+
+	body, err := compress(entry.Body, c.Compression)
+	if err != nil {
+		return errors.Wrap(err, "failed to compress cache file")
+	}
+	header, err := json.Marshal(cacheMeta{Key: key, ETag: entry.ETag, LastModified: entry.LastModified, StatusCode: entry.StatusCode})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode cache meta")
+	}
+	data := append(append(header, '\n'), body...)
 
-	baseDir := path.Join(getScrapboxHomeDir(), "query", trimPortFromHost(host), project, path.Join(tags...))
-	queryResultFilePath := path.Join(baseDir, EncodeFilename(fmt.Sprintf("%d-%d", skip, limit)))
-	fs, err := os.Stat(queryResultFilePath)
+	entryFilePath := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(entryFilePath), os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to make cache directory")
+	}
+
+	unlock, err := lockFile(entryFilePath + lockFileSuffix)
 	if err != nil {
-		return false
+		return err
 	}
-	if fs.IsDir() {
-		return false
+	defer unlock()
+
+	if err := writeFileAtomic(entryFilePath, data, entry.StoredAt); err != nil {
+		return errors.Wrap(err, "failed to write cache file")
 	}
-	mod := fs.ModTime()
-	now := time.Now()
-	duration := now.Sub(mod)
 
-	return duration <= expiration
+	return nil
 }
 
-func openQueryResultFile(host, project string, tags []string, skip, limit int) (*os.File, error) {
+func (c *FileCache) Invalidate(key string) error {
 
-	baseDir := path.Join(getScrapboxHomeDir(), "query", trimPortFromHost(host), project, path.Join(tags...))
-	queryResultFilePath := path.Join(baseDir, EncodeFilename(fmt.Sprintf("%d-%d", skip, limit)))
-	queryResultFile, err := os.Open(queryResultFilePath)
+	c.migrate()
+
+	entryFilePath := c.path(key)
+	if _, err := os.Stat(filepath.Dir(entryFilePath)); os.IsNotExist(err) {
+		return nil
+	}
+
+	unlock, err := lockFile(entryFilePath + lockFileSuffix)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to open query cache file")
+		return err
 	}
+	defer unlock()
 
-	return queryResultFile, nil
+	if err := os.Remove(entryFilePath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove cache file")
+	}
+	return nil
 }
 
-func createPageFile(host, project, page string) (*os.File, error) {
+func readCacheMeta(name string) (*cacheMeta, error) {
+
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
 
-	baseDir := path.Join(getScrapboxHomeDir(), "page", trimPortFromHost(host), project)
-	if err := os.MkdirAll(baseDir, os.ModePerm); err != nil {
-		return nil, errors.Wrap(err, "failed to make page cache directory")
+	var meta cacheMeta
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return nil, err
 	}
-	pageFilePath := path.Join(baseDir, EncodeFilename(page))
-	pageFile, err := os.Create(pageFilePath)
+	return &meta, nil
+}
+
+// readCacheHeader reads the first line of the file of FileCache.
+func readCacheHeader(r *bufio.Reader) (*cacheMeta, error) {
+
+	b, err := r.ReadBytes('\n')
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create page cache file")
+		return nil, err
 	}
 
-	return pageFile, nil
+	var meta cacheMeta
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
 }
 
-func haveGoodPageFile(host, project, page string, expiration time.Duration) bool {
+// readCacheFileKey returns the key of the file of FileCache.
+func readCacheFileKey(name string) (string, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
 
-	baseDir := path.Join(getScrapboxHomeDir(), "page", trimPortFromHost(host), project)
-	pageFilePath := path.Join(baseDir, EncodeFilename(page))
-	fs, err := os.Stat(pageFilePath)
+	meta, err := readCacheHeader(bufio.NewReader(f))
 	if err != nil {
-		return false
+		return "", err
+	}
+	return meta.Key, nil
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and renames it to name,
+// so that readers see either the old or the new content. The temporary file is removed on failure.
+func writeFileAtomic(name string, data []byte, modTime time.Time) (err error) {
+
+	f, err := ioutil.TempFile(filepath.Dir(name), tempFilePrefix)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
 	}
-	if fs.IsDir() {
-		return false
+	if err = os.Chmod(f.Name(), 0644); err != nil {
+		return err
 	}
-	mod := fs.ModTime()
-	now := time.Now()
-	duration := now.Sub(mod)
+	if !modTime.IsZero() {
+		if err = os.Chtimes(f.Name(), modTime, modTime); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(f.Name(), name)
+}
 
-	return duration <= expiration
+func (c *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.Dir, entriesDir, name[:2], name)
 }
 
-func openPageFile(host, project, page string) (*os.File, error) {
+const (
+	CacheKindPage  = "page"
+	CacheKindQuery = "query"
+)
+
+// CachedFile describes an entry stored in FileCache.
+type CachedFile struct {
+	Key  string
+	Kind string
+	Host string
+	// Project is the project name, and Name is the page title or the tags and range of the query.
+	Project  string
+	Name     string
+	Size     int64
+	StoredAt time.Time
+}
+
+// Files returns the entries stored under Dir.
+func (c *FileCache) Files() ([]CachedFile, error) {
+
+	c.migrate()
 
-	baseDir := path.Join(getScrapboxHomeDir(), "page", trimPortFromHost(host), project)
-	pageFilePath := path.Join(baseDir, EncodeFilename(page))
-	pageFile, err := os.Open(pageFilePath)
+	var files []CachedFile
+
+	err := filepath.Walk(filepath.Join(c.Dir, entriesDir), func(name string, fs os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if fs.IsDir() || isCacheWorkFile(fs.Name()) {
+			return nil
+		}
+
+		key, err := readCacheFileKey(name)
+		if err != nil {
+			return nil
+		}
+		kind, host, project, title, ok := parseCacheKey(key)
+		if !ok {
+			return nil
+		}
+
+		files = append(files, CachedFile{
+			Key:      key,
+			Kind:     kind,
+			Host:     host,
+			Project:  project,
+			Name:     title,
+			Size:     fs.Size(),
+			StoredAt: fs.ModTime(),
+		})
+
+		return nil
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to open page cache file")
+		return nil, errors.Wrap(err, "failed to walk cache directory")
 	}
 
-	return pageFile, nil
+	return files, nil
 }
 
-func getScrapboxHomeDir() string {
-	value := os.Getenv(EnvHome)
-	if len(value) == 0 {
-		if userHomeDir, err := homedir.Dir(); err != nil {
-			value = path.Join(userHomeDir, ".scrapbox")
-		} else {
-			value = "./.scrapbox"
+// Clean removes temporary and lock files left behind by crashed processes, files without the key,
+// and empty directories.
+func (c *FileCache) Clean() error {
+
+	c.migrate()
+
+	var dirs []string
+
+	err := filepath.Walk(filepath.Join(c.Dir, entriesDir), func(name string, fs os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
 		}
-	} else {
-		if expanded, err := homedir.Expand(value); err != nil {
-			value = expanded
+		if fs.IsDir() {
+			dirs = append(dirs, name)
+			return nil
+		}
+		if time.Now().Sub(fs.ModTime()) <= lockStaleAge {
+			return nil
 		}
+		if isCacheWorkFile(fs.Name()) {
+			os.Remove(name)
+		} else if _, err := readCacheFileKey(name); err != nil {
+			os.Remove(name)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to walk cache directory")
+	}
+
+	// children come after their parent in walk order
+	for i := len(dirs) - 1; i > 0; i-- {
+		os.Remove(dirs[i])
+	}
+
+	return nil
+}
+
+func isCacheWorkFile(name string) bool {
+	return strings.HasPrefix(name, tempFilePrefix) || strings.HasSuffix(name, lockFileSuffix)
+}
+
+// PageCacheKey returns the key of the page response. The port of the host is ignored.
+func PageCacheKey(host, project, page string) string {
+	return joinCacheKey(CacheKindPage, trimPortFromHost(host), project, page)
+}
+
+// QueryCacheKey returns the key of the page list response. The port of the host is ignored.
+func QueryCacheKey(host, project string, tags []string, skip, limit int, sort string) string {
+	parts := []string{CacheKindQuery, trimPortFromHost(host), project}
+	parts = append(parts, tags...)
+	parts = append(parts, queryResultFilename(skip, limit, sort))
+	return joinCacheKey(parts...)
+}
+
+func queryResultFilename(skip, limit int, sort string) string {
+	if sort == DefaultSort {
+		return fmt.Sprintf("%d-%d", skip, limit)
+	}
+	return fmt.Sprintf("%d-%d-%s", skip, limit, sort)
+}
+
+// joinCacheKey escapes the parts, so that the key is split back into them unambiguously.
+func joinCacheKey(parts ...string) string {
+	escaped := make([]string, len(parts))
+	for i, p := range parts {
+		escaped[i] = url.PathEscape(p)
+	}
+	return strings.Join(escaped, "/")
+}
+
+// parseCacheKey splits the key into the kind, the host, the project and the name,
+// which is the page title or the tags and the range of the query joined by slashes.
+func parseCacheKey(key string) (kind, host, project, name string, ok bool) {
+
+	parts := strings.Split(key, "/")
+	if len(parts) < 4 {
+		return "", "", "", "", false
+	}
+	for i, p := range parts {
+		unescaped, err := url.PathUnescape(p)
+		if err != nil {
+			return "", "", "", "", false
+		}
+		parts[i] = unescaped
+	}
+
+	kind, host, project = parts[0], parts[1], parts[2]
+	switch {
+	case kind == CacheKindPage && len(parts) == 4:
+		return kind, host, project, parts[3], true
+	case kind == CacheKindQuery:
+		return kind, host, project, strings.Join(parts[3:], "/"), true
+	default:
+		return "", "", "", "", false
 	}
-	return value
 }
 
 func EncodeFilename(filename string) string {
@@ -128,6 +442,13 @@ func EncodeFilename(filename string) string {
 	return pipeEscaped
 }
 
+func DecodeFilename(filename string) string {
+	pipeUnescaped := strings.Replace(filename, "%7C", "|", -1)
+	colonUnescaped := strings.Replace(pipeUnescaped, "%3A", ":", -1)
+	slashUnescaped := strings.Replace(colonUnescaped, "%2F", "/", -1)
+	return slashUnescaped
+}
+
 func trimPortFromHost(host string) string {
 	if index := strings.Index(host, ":"); index == -1 {
 		return host
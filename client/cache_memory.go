@@ -0,0 +1,57 @@
+package client
+
+import (
+	"sync"
+)
+
+// MemoryCache keeps entries in the process memory.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]CacheEntry
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]CacheEntry{}}
+}
+
+func (c *MemoryCache) Get(key string) (*CacheEntry, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	return &entry, nil
+}
+
+func (c *MemoryCache) Put(key string, entry *CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = *entry
+	return nil
+}
+
+func (c *MemoryCache) Invalidate(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+	return nil
+}
+
+// NopCache stores nothing, so that every request goes to the api.
+type NopCache struct{}
+
+func (NopCache) Get(key string) (*CacheEntry, error) {
+	return nil, ErrCacheMiss
+}
+
+func (NopCache) Put(key string, entry *CacheEntry) error {
+	return nil
+}
+
+func (NopCache) Invalidate(key string) error {
+	return nil
+}
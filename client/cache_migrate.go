@@ -0,0 +1,176 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// migrate moves the entries stored in the layout before hashed filenames, that is
+// page/<host>/<project>/<encoded title> and query/<host>/<project>/<tags...>/<range>,
+// into the current layout. It runs once per FileCache, and is safe to run by processes at once.
+func (c *FileCache) migrate() {
+	c.migrateOnce.Do(func() {
+		for _, kind := range []string{CacheKindPage, CacheKindQuery} {
+			root := filepath.Join(c.Dir, kind)
+			if _, err := os.Stat(root); err != nil {
+				continue
+			}
+			c.migrateDir(root, kind)
+		}
+	})
+}
+
+// hashedWorkFile matches the temporary files and the lock files of the current layout.
+// The other files, such as the page titled x.lock, are migrated as the entries.
+var hashedWorkFile = regexp.MustCompile("^(\\.tmp-[0-9]+|[0-9a-f]{64}\\.lock)$")
+
+func (c *FileCache) migrateDir(root, kind string) {
+
+	var dirs []string
+
+	filepath.Walk(root, func(name string, fs os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if fs.IsDir() {
+			dirs = append(dirs, name)
+			return nil
+		}
+		if strings.HasSuffix(name, metaFileSuffix) {
+			if _, err := os.Stat(strings.TrimSuffix(name, metaFileSuffix)); err == nil {
+				// moved along with the entry
+				return nil
+			}
+		}
+		if hashedWorkFile.MatchString(fs.Name()) {
+			os.Remove(name)
+			return nil
+		}
+
+		if !c.migrateFile(name, fs) {
+			c.unmigrated = append(c.unmigrated, name)
+		}
+		return nil
+	})
+
+	// directories having the files left are not removed
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+}
+
+// migrateFile stores the legacy file as the entry, and removes it only if stored.
+func (c *FileCache) migrateFile(name string, fs os.FileInfo) bool {
+
+	rel, err := filepath.Rel(c.Dir, name)
+	if err != nil {
+		return false
+	}
+	key, ok := legacyCacheKey(strings.Split(filepath.ToSlash(rel), "/"))
+	if !ok {
+		return false
+	}
+	body, err := ioutil.ReadFile(name)
+	if err != nil {
+		return false
+	}
+
+	entry := &CacheEntry{Body: body, StoredAt: fs.ModTime()}
+	if meta, err := readCacheMeta(name + metaFileSuffix); err == nil {
+		entry.ETag = meta.ETag
+		entry.LastModified = meta.LastModified
+	}
+	if err := c.put(key, entry); err != nil {
+		return false
+	}
+
+	os.Remove(name)
+	os.Remove(name + metaFileSuffix)
+	return true
+}
+
+// Unmigrated returns the files left in the layout before hashed filenames,
+// which are not recognized or failed to be migrated.
+func (c *FileCache) Unmigrated() []string {
+	c.migrate()
+	return c.unmigrated
+}
+
+// legacyCacheKey returns the key of the file in the layout before hashed filenames.
+func legacyCacheKey(parts []string) (string, bool) {
+
+	if len(parts) < 4 {
+		return "", false
+	}
+	kind, host, project := parts[0], parts[1], parts[2]
+
+	if kind == CacheKindPage {
+		if len(parts) != 4 {
+			return "", false
+		}
+		return PageCacheKey(host, project, DecodeFilename(parts[3])), true
+	}
+
+	// the range is "skip-limit" or "skip-limit-sort"
+	tags := parts[3 : len(parts)-1]
+	fields := strings.SplitN(DecodeFilename(parts[len(parts)-1]), "-", 3)
+	if len(fields) < 2 {
+		return "", false
+	}
+	skip, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return "", false
+	}
+	limit, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", false
+	}
+	sort := DefaultSort
+	if len(fields) == 3 {
+		sort = fields[2]
+	}
+	return QueryCacheKey(host, project, tags, skip, limit, sort), true
+}
+
+// MoveFileCache moves the entries of src into dst, keeping the newer one of the same key,
+// and removes the directory of src if it becomes empty. It returns the number of entries moved.
+func MoveFileCache(src, dst *FileCache) (int, error) {
+
+	files, err := src.Files()
+	if err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	for _, f := range files {
+		entry, err := src.Get(f.Key)
+		if err == ErrCacheMiss {
+			continue
+		}
+		if err != nil {
+			return moved, err
+		}
+
+		if existing, err := dst.Get(f.Key); err != nil || existing.StoredAt.Before(entry.StoredAt) {
+			if err := dst.Put(f.Key, entry); err != nil {
+				return moved, err
+			}
+			moved++
+		}
+		if err := src.Invalidate(f.Key); err != nil {
+			return moved, err
+		}
+	}
+
+	if err := src.Clean(); err != nil {
+		return moved, err
+	}
+	os.Remove(filepath.Join(src.Dir, entriesDir))
+	os.Remove(src.Dir)
+
+	return moved, nil
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CachePolicy controls when the client serves expired entries of the cache.
+// Both windows start when the entry expires. Zero disables the policy.
+type CachePolicy struct {
+	// StaleWhileRevalidate serves the expired entry at once while it is revalidated in background.
+	// It suits long-lived processes, which call Client.Wait before exiting.
+	StaleWhileRevalidate time.Duration
+	// StaleIfError serves the expired entry when the request fails on the network or the server side.
+	StaleIfError time.Duration
+}
+
+func (p CachePolicy) serveWhileRevalidate(entry *CacheEntry, expiration time.Duration) bool {
+	return p.StaleWhileRevalidate > 0 && !entry.Expired(expiration+p.StaleWhileRevalidate)
+}
+
+func (p CachePolicy) serveIfError(entry *CacheEntry, expiration time.Duration, err error) bool {
+
+	if p.StaleIfError <= 0 || entry.Expired(expiration+p.StaleIfError) {
+		return false
+	}
+
+	// the answers of the api and the cancellation by the caller are not failures to hide
+	switch errors.Cause(err).(type) {
+	case *NotFoundError, *UnauthorizedError:
+		return false
+	}
+	return errors.Cause(err) != context.Canceled
+}
@@ -0,0 +1,244 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache__round_trip(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "scrapbox-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	storedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	key := PageCacheKey("localhost:8080", "go-scrapbox", "a/b:c")
+
+	for name, cache := range map[string]Cache{
+		"file":   NewFileCache(dir),
+		"memory": NewMemoryCache(),
+	} {
+		if _, err := cache.Get(key); err != ErrCacheMiss {
+			t.Fatalf("%s: Got %v, but Want %v", name, err, ErrCacheMiss)
+		}
+
+		if err := cache.Put(key, &CacheEntry{Body: []byte("{}"), StoredAt: storedAt, ETag: `"v1"`}); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		entry, err := cache.Get(key)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if string(entry.Body) != "{}" {
+			t.Fatalf("%s: Got %q, but Want %q", name, entry.Body, "{}")
+		}
+		if !entry.StoredAt.Equal(storedAt) {
+			t.Fatalf("%s: Got %v, but Want %v", name, entry.StoredAt, storedAt)
+		}
+		if entry.ETag != `"v1"` || entry.LastModified != "" {
+			t.Fatalf("%s: Got %q and %q, but Want validators stored", name, entry.ETag, entry.LastModified)
+		}
+		if entry.Expired(time.Hour) || !entry.Expired(time.Second) {
+			t.Fatalf("%s: stored at %v, but expiration is wrong", name, entry.StoredAt)
+		}
+
+		if err := cache.Invalidate(key); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if _, err := cache.Get(key); err != ErrCacheMiss {
+			t.Fatalf("%s: Got %v, but Want %v", name, err, ErrCacheMiss)
+		}
+		if err := cache.Invalidate(key); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+	}
+}
+
+func TestCache__nop(t *testing.T) {
+
+	cache := NopCache{}
+	if err := cache.Put("key", &CacheEntry{Body: []byte("{}")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Get("key"); err != ErrCacheMiss {
+		t.Fatalf("Got %v, but Want %v", err, ErrCacheMiss)
+	}
+}
+
+func TestFileCache__concurrent_put(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "scrapbox-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := NewFileCache(dir)
+	key := PageCacheKey("localhost", "go-scrapbox", "title")
+
+	// the status code is 404 for the odd bodies
+	bodies := map[string]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		body := strings.Repeat(strconv.Itoa(i), 64*1024)
+		status := 0
+		if i%2 == 1 {
+			status = http.StatusNotFound
+		}
+		bodies[body] = status
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cache.Put(key, &CacheEntry{Body: []byte(body), ETag: body[:1], StatusCode: status}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	torn := func(entry *CacheEntry) bool {
+		status, ok := bodies[string(entry.Body)]
+		return !ok || entry.ETag != string(entry.Body[:1]) || entry.StatusCode != status
+	}
+	for i := 0; i < 100; i++ {
+		if entry, err := cache.Get(key); err == nil && torn(entry) {
+			t.Fatalf("Got torn entry of %d bytes with %q and %d", len(entry.Body), entry.ETag, entry.StatusCode)
+		}
+	}
+	wg.Wait()
+
+	entry, err := cache.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if torn(entry) {
+		t.Fatalf("Got torn entry of %d bytes with %q and %d", len(entry.Body), entry.ETag, entry.StatusCode)
+	}
+
+	name := filepath.Base(cache.path(key))
+	files, _ := ioutil.ReadDir(filepath.Dir(cache.path(key)))
+	for _, f := range files {
+		if f.Name() != name {
+			t.Fatalf("%s is left behind", f.Name())
+		}
+	}
+}
+
+func TestFileCache__migrate_legacy_layout(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "scrapbox-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, body := range map[string]string{
+		"page/scrapbox.io/go-scrapbox/a%2Fb%3Ac":        "page",
+		"query/scrapbox.io/go-scrapbox/english/0-100":   "query",
+		"query/scrapbox.io/go-scrapbox/0-100-title":     "sorted",
+		"query/scrapbox.io/go-scrapbox/english/unknown": "unknown",
+		"page/scrapbox.io/go-scrapbox/x.lock":           "lock",
+		"page/scrapbox.io/go-scrapbox/.tmp-12345":       "partial",
+	} {
+		legacyFilePath := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(legacyFilePath), os.ModePerm)
+		if err := ioutil.WriteFile(legacyFilePath, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cache := NewFileCache(dir)
+	for key, body := range map[string]string{
+		PageCacheKey("scrapbox.io", "go-scrapbox", "a/b:c"):                                   "page",
+		QueryCacheKey("scrapbox.io", "go-scrapbox", []string{"english"}, 0, 100, DefaultSort): "query",
+		QueryCacheKey("scrapbox.io", "go-scrapbox", nil, 0, 100, SortTitle):                   "sorted",
+		PageCacheKey("scrapbox.io", "go-scrapbox", "x.lock"):                                  "lock",
+	} {
+		entry, err := cache.Get(key)
+		if err != nil {
+			t.Fatalf("%s: %s", key, err)
+		}
+		if string(entry.Body) != body {
+			t.Fatalf("%s: Got %q, but Want %q", key, entry.Body, body)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, CacheKindPage)); !os.IsNotExist(err) {
+		t.Fatalf("%s directory is left behind", CacheKindPage)
+	}
+
+	unknownFilePath := filepath.Join(dir, filepath.FromSlash("query/scrapbox.io/go-scrapbox/english/unknown"))
+	if _, err := os.Stat(unknownFilePath); err != nil {
+		t.Fatalf("unknown file is removed: %s", err)
+	}
+	if unmigrated := cache.Unmigrated(); len(unmigrated) != 1 || unmigrated[0] != unknownFilePath {
+		t.Fatalf("Got %v, but Want %v", unmigrated, []string{unknownFilePath})
+	}
+}
+
+func TestCacheKey__unambiguous(t *testing.T) {
+
+	keys := map[string]bool{}
+	for _, key := range []string{
+		PageCacheKey("scrapbox.io", "go-scrapbox", "a/b"),
+		PageCacheKey("scrapbox.io", "go-scrapbox", "a%2Fb"),
+		PageCacheKey("scrapbox.io", "go-scrapbox", ".."),
+		QueryCacheKey("scrapbox.io", "go-scrapbox", []string{"a/b"}, 0, 100, DefaultSort),
+		QueryCacheKey("scrapbox.io", "go-scrapbox", []string{"a", "b"}, 0, 100, DefaultSort),
+		QueryCacheKey("scrapbox.io", "go-scrapbox", []string{".."}, 0, 100, DefaultSort),
+	} {
+		if keys[key] {
+			t.Fatalf("%s collides", key)
+		}
+		keys[key] = true
+
+		if _, _, _, _, ok := parseCacheKey(key); !ok {
+			t.Fatalf("%s is not parsed", key)
+		}
+	}
+}
+
+func TestFileCache__compression(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "scrapbox-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	body := []byte(strings.Repeat(`{"title":"title"}`, 100))
+	cache := NewFileCache(dir)
+	for i, compression := range Compressions {
+		key := PageCacheKey("localhost", "go-scrapbox", compression)
+		cache.Compression = compression
+		if err := cache.Put(key, &CacheEntry{Body: body}); err != nil {
+			t.Fatalf("%s: %s", compression, err)
+		}
+
+		data, err := ioutil.ReadFile(cache.path(key))
+		if err != nil {
+			t.Fatalf("%s: %s", compression, err)
+		}
+		if compressed := len(data) < len(body); compressed != (i != 0) {
+			t.Fatalf("%s: stored %d bytes of %d bytes", compression, len(data), len(body))
+		}
+
+		// read with another compression
+		cache.Compression = CompressionNone
+		entry, err := cache.Get(key)
+		if err != nil {
+			t.Fatalf("%s: %s", compression, err)
+		}
+		if string(entry.Body) != string(body) {
+			t.Fatalf("%s: Got %q", compression, entry.Body)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+var Compressions = []string{CompressionNone, CompressionGzip, CompressionZstd}
+
+func IsValidCompression(compression string) bool {
+	for _, c := range Compressions {
+		if c == compression {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// zstdCodec returns the encoder and the decoder shared by goroutines.
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		if zstdEncoder, zstdErr = zstd.NewWriter(nil); zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+// compress encodes data with the compression. Empty compression means none.
+func compress(data []byte, compression string) ([]byte, error) {
+
+	switch compression {
+	case "", CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		buf := new(bytes.Buffer)
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		encoder, _, err := zstdCodec()
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(data, nil), nil
+	default:
+		return nil, fmt.Errorf("compression must be one of %s. compression: %s", strings.Join(Compressions, ", "), compression)
+	}
+}
+
+// decompress decodes data by its magic number, so that entries stored with any compression are read.
+// Raw json never starts with the magic numbers.
+func decompress(data []byte) ([]byte, error) {
+
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return ioutil.ReadAll(r)
+	case bytes.HasPrefix(data, zstdMagic):
+		_, decoder, err := zstdCodec()
+		if err != nil {
+			return nil, err
+		}
+		return decoder.DecodeAll(data, nil)
+	default:
+		return data, nil
+	}
+}
+
+type gzipBody struct {
+	*gzip.Reader
+	body io.Closer
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// decodeContentEncoding replaces the body of the response compressed by the server
+// with the decompressing one, since requesting Accept-Encoding disables it in net/http.
+func decodeContentEncoding(res *http.Response) error {
+
+	if !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+
+	r, err := gzip.NewReader(res.Body)
+	if err == io.EOF {
+		// no body, such as 304
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(nil))
+	} else if err != nil {
+		res.Body.Close()
+		return errors.Wrap(err, "failed to decode gzip response")
+	} else {
+		res.Body = &gzipBody{Reader: r, body: res.Body}
+	}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+
+	return nil
+}
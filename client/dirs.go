@@ -0,0 +1,54 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	EnvHome          = "SCRAPBOX_HOME"
+	EnvXDGCacheHome  = "XDG_CACHE_HOME"
+	EnvXDGConfigHome = "XDG_CONFIG_HOME"
+)
+
+// LegacyHomeDirs are the directories used by the previous versions, where local caches may be left.
+var LegacyHomeDirs = []string{"./.scrapbox", "~/.scrapbox"}
+
+// DefaultCacheDir returns the directory of the FileCache used by NewClient.
+// SCRAPBOX_HOME takes precedence over $XDG_CACHE_HOME/scrapbox, which is ~/.cache/scrapbox by default.
+func DefaultCacheDir() string {
+	return xdgDir(EnvXDGCacheHome, ".cache")
+}
+
+// DefaultConfigDir returns the directory of the user configuration.
+// SCRAPBOX_HOME takes precedence over $XDG_CONFIG_HOME/scrapbox, which is ~/.config/scrapbox by default.
+func DefaultConfigDir() string {
+	return xdgDir(EnvXDGConfigHome, ".config")
+}
+
+func xdgDir(env, defaultBase string) string {
+
+	if value := os.Getenv(EnvHome); len(value) != 0 {
+		return ExpandHomeDir(value)
+	}
+
+	// relative paths are invalid by the XDG Base Directory Specification
+	if base := os.Getenv(env); filepath.IsAbs(base) {
+		return filepath.Join(base, "scrapbox")
+	}
+
+	if userHomeDir, err := homedir.Dir(); err == nil {
+		return filepath.Join(userHomeDir, defaultBase, "scrapbox")
+	}
+	return "./.scrapbox"
+}
+
+// ExpandHomeDir expands the leading ~ of the path into the user home directory, if possible.
+func ExpandHomeDir(path string) string {
+	if expanded, err := homedir.Expand(path); err == nil {
+		return expanded
+	}
+	return path
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+func TestDefaultCacheDir(t *testing.T) {
+
+	homedir.DisableCache = true
+	defer func() {
+		homedir.DisableCache = false
+	}()
+
+	for _, name := range []string{EnvHome, EnvXDGCacheHome, EnvXDGConfigHome, "HOME"} {
+		defer os.Setenv(name, os.Getenv(name))
+	}
+
+	for _, fixture := range []struct {
+		home      string
+		xdgCache  string
+		xdgConfig string
+		cacheDir  string
+		configDir string
+	}{
+		{"", "", "", "/home/user/.cache/scrapbox", "/home/user/.config/scrapbox"},
+		{"", "/xdg/cache", "/xdg/config", "/xdg/cache/scrapbox", "/xdg/config/scrapbox"},
+		{"", "relative", "relative", "/home/user/.cache/scrapbox", "/home/user/.config/scrapbox"},
+		{"~/sb", "/xdg/cache", "/xdg/config", "/home/user/sb", "/home/user/sb"},
+		{"/sb", "", "", "/sb", "/sb"},
+	} {
+		os.Setenv("HOME", "/home/user")
+		os.Setenv(EnvHome, fixture.home)
+		os.Setenv(EnvXDGCacheHome, fixture.xdgCache)
+		os.Setenv(EnvXDGConfigHome, fixture.xdgConfig)
+
+		if dir := DefaultCacheDir(); dir != filepath.FromSlash(fixture.cacheDir) {
+			t.Fatalf("Got %s, but Want %s", dir, fixture.cacheDir)
+		}
+		if dir := DefaultConfigDir(); dir != filepath.FromSlash(fixture.configDir) {
+			t.Fatalf("Got %s, but Want %s", dir, fixture.configDir)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const maxErrorBodySize = 64 * 1024
+
+// HTTPError describes a non-200 response of the scrapbox api.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	URL        string
+	Body       []byte
+
+	// Name and Message are taken from the error json of scrapbox, if any.
+	Name    string
+	Message string
+}
+
+func (e *HTTPError) Error() string {
+	if len(e.Message) != 0 {
+		return fmt.Sprintf("http status is %q. url: %s, message: %s", e.Status, e.URL, e.Message)
+	}
+	return fmt.Sprintf("http status is %q. url: %s", e.Status, e.URL)
+}
+
+// NotCachedError is returned in offline mode when the response is not in the cache.
+type NotCachedError struct {
+	Key string
+}
+
+func (e *NotCachedError) Error() string {
+	return fmt.Sprintf("not cached in offline mode. key: %s", e.Key)
+}
+
+// NotFoundError is returned when the project or the page does not exist.
+type NotFoundError struct {
+	*HTTPError
+}
+
+// ProjectNotFound reports whether the project itself is missing, not the page.
+func (e *NotFoundError) ProjectNotFound() bool {
+	return strings.Contains(strings.ToLower(e.Message), "project")
+}
+
+// UnauthorizedError is returned when the project is private and the token is missing or not a member's.
+type UnauthorizedError struct {
+	*HTTPError
+}
+
+// RateLimitedError is returned when scrapbox throttles the client.
+type RateLimitedError struct {
+	*HTTPError
+}
+
+// ServerError is returned when scrapbox fails with 5xx status.
+type ServerError struct {
+	*HTTPError
+}
+
+func newHTTPError(res *http.Response) error {
+	defer res.Body.Close()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+
+	return newStatusError(res.StatusCode, res.Status, res.Request.URL.String(), body)
+}
+
+func newStatusError(statusCode int, status, url string, body []byte) error {
+
+	e := &HTTPError{
+		StatusCode: statusCode,
+		Status:     status,
+		URL:        url,
+		Body:       body,
+	}
+
+	var v struct {
+		Name    string `json:"name"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &v); err == nil {
+		e.Name = v.Name
+		e.Message = v.Message
+	}
+
+	switch {
+	case statusCode == http.StatusNotFound:
+		return &NotFoundError{e}
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return &UnauthorizedError{e}
+	case statusCode == http.StatusTooManyRequests:
+		return &RateLimitedError{e}
+	case statusCode >= 500:
+		return &ServerError{e}
+	default:
+		return e
+	}
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	lockRetryInterval = 10 * time.Millisecond
+	lockTimeout       = 10 * time.Second
+	// lockStaleAge is the age of a lock file left behind by a crashed process.
+	lockStaleAge = 30 * time.Second
+)
+
+// lockFile acquires the lock by creating name exclusively, which works on every platform
+// and file system unlike flock. It returns the function to release the lock.
+func lockFile(name string) (func(), error) {
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d", os.Getpid())
+			f.Close()
+			return func() { os.Remove(name) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errors.Wrap(err, "failed to create lock file")
+		}
+
+		if fs, err := os.Stat(name); err == nil && time.Now().Sub(fs.ModTime()) > lockStaleAge {
+			os.Remove(name)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("failed to acquire lock file. file: %s", name)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	SortUpdated  = "updated"
+	SortCreated  = "created"
+	SortAccessed = "accessed"
+	SortLinked   = "linked"
+	SortViews    = "views"
+	SortTitle    = "title"
+)
+
+const (
+	DefaultSort  = SortUpdated
+	DefaultLimit = 100
+)
+
+var Sorts = []string{SortUpdated, SortCreated, SortAccessed, SortLinked, SortViews, SortTitle}
+
+func IsValidSort(sort string) bool {
+	for _, s := range Sorts {
+		if s == sort {
+			return true
+		}
+	}
+	return false
+}
+
+type QueryOptions struct {
+	// Skip is the number of pages skipped from the beginning.
+	Skip int
+	// Limit is the number of pages fetched by one request.
+	Limit int
+	// Sort is the sort order of pages. By default, "updated".
+	Sort string
+	// Max is the number of pages yielded in total. Zero means all pages.
+	Max int
+}
+
+// PageIterator walks pages of the project one response at a time,
+// so that callers can process pages as they arrive and stop early.
+//
+//	it := c.Pages(project, tags, QueryOptions{})
+//	for it.Next(ctx) {
+//		fmt.Println(it.Page().Title)
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type PageIterator struct {
+	client  *Client
+	project string
+	tags    []string
+	limit   int
+	sort    string
+	max     int
+
+	skip    int
+	yielded int
+	count   int
+	fetched bool
+	last    bool
+	buffer  []PageSummary
+	current PageSummary
+	err     error
+}
+
+func (c *Client) Pages(project string, tags []string, opts QueryOptions) *PageIterator {
+
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+	if opts.Max > 0 && opts.Max < opts.Limit {
+		opts.Limit = opts.Max
+	}
+	if len(opts.Sort) == 0 {
+		opts.Sort = DefaultSort
+	}
+
+	return &PageIterator{
+		client:  c,
+		project: project,
+		tags:    tags,
+		limit:   opts.Limit,
+		sort:    opts.Sort,
+		max:     opts.Max,
+		skip:    opts.Skip,
+	}
+}
+
+// Next advances the iterator, fetching the next response if needed.
+// It returns false at the end of pages or on error.
+func (it *PageIterator) Next(ctx context.Context) bool {
+
+	if it.max > 0 && it.yielded >= it.max {
+		return false
+	}
+
+	for len(it.buffer) == 0 {
+		if it.err != nil || it.last {
+			return false
+		}
+		it.fetch(ctx)
+	}
+
+	it.current, it.buffer = it.buffer[0], it.buffer[1:]
+	it.yielded++
+	return true
+}
+
+// Page returns the page at the current position.
+func (it *PageIterator) Page() PageSummary {
+	return it.current
+}
+
+// Count returns the count reported by the first response.
+func (it *PageIterator) Count() int {
+	return it.count
+}
+
+// Err returns the error stopped the iteration, if any.
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+func (it *PageIterator) fetch(ctx context.Context) {
+
+	v, err := it.client.fetchPageList(ctx, it.project, it.tags, it.skip, it.limit, it.sort)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	if !it.fetched {
+		it.count = v.Count
+		it.fetched = true
+	}
+
+	for _, p := range v.Pages {
+		if matchTags(p, it.tags) {
+			it.buffer = append(it.buffer, p)
+		}
+	}
+
+	skip := it.skip
+	it.skip += it.limit
+	it.last = len(v.Pages) == 0 || !(v.Count > it.limit+skip || v.Count == it.limit)
+}
+
+func matchTags(p PageSummary, tags []string) bool {
+
+	if len(tags) == 0 {
+		return true
+	}
+
+	for _, s := range p.Snipet {
+		all := true
+		for _, t := range tags {
+			all = all &&
+				(strings.Contains(strings.ToLower(s), fmt.Sprintf("<b>%s</b>", strings.ToLower(t))) ||
+					strings.Contains(strings.ToLower(p.Title), strings.ToLower(t)))
+		}
+		if all {
+			return true
+		}
+	}
+
+	return false
+}
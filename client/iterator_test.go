@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func RunPageListAPIServer(count int, requests *int) *httptest.Server {
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		v := PageList{Skip: skip, Limit: limit, Count: count, Pages: []PageSummary{}}
+		for i := skip; i < skip+limit && i < count; i++ {
+			v.Pages = append(v.Pages, PageSummary{Title: fmt.Sprintf("page%d", i)})
+		}
+		json.NewEncoder(w).Encode(v)
+	}))
+}
+
+func TestPageIterator__all_pages(t *testing.T) {
+
+	requests := 0
+	testAPIServer := RunPageListAPIServer(5, &requests)
+	defer testAPIServer.Close()
+
+	u, _ := url.Parse(testAPIServer.URL)
+	c, _ := NewClient(u, "", 0, DefaultUserAgent)
+
+	titles := []string{}
+	it := c.Pages("iterator-all", nil, QueryOptions{Limit: 2})
+	for it.Next(context.Background()) {
+		titles = append(titles, it.Page().Title)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(titles) != "[page0 page1 page2 page3 page4]" {
+		t.Fatalf("Got %v", titles)
+	}
+	if it.Count() != 5 {
+		t.Fatalf("Count is %d, but want %d", it.Count(), 5)
+	}
+	if requests != 3 {
+		t.Fatalf("Requests are %d, but want %d", requests, 3)
+	}
+}
+
+func TestPageIterator__stop_early(t *testing.T) {
+
+	requests := 0
+	testAPIServer := RunPageListAPIServer(5, &requests)
+	defer testAPIServer.Close()
+
+	u, _ := url.Parse(testAPIServer.URL)
+	c, _ := NewClient(u, "", 0, DefaultUserAgent)
+
+	it := c.Pages("iterator-stop", nil, QueryOptions{Skip: 1, Limit: 2})
+	for i := 0; i < 2 && it.Next(context.Background()); i++ {
+	}
+
+	if it.Page().Title != "page2" {
+		t.Fatalf("Got %s, but want %s", it.Page().Title, "page2")
+	}
+	if requests != 1 {
+		t.Fatalf("Requests are %d, but want %d", requests, 1)
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RateLimiter is a token bucket shared by every request of the client.
+// It is safe for concurrent use.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// ParseRateLimit builds a RateLimiter from "RATE" or "RATE:BURST", where RATE is requests per second.
+// It returns nil for an empty value, which means no limit.
+func ParseRateLimit(value string) (*RateLimiter, error) {
+
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	var (
+		rateValue  = value
+		burstValue = ""
+	)
+	if index := strings.Index(value, ":"); index != -1 {
+		rateValue, burstValue = value[:index], value[index+1:]
+	}
+
+	rate, err := strconv.ParseFloat(rateValue, 64)
+	if err != nil || rate <= 0 {
+		return nil, errors.Errorf("invalid rate limit %q: rate must be a positive number", value)
+	}
+
+	burst := 1
+	if len(burstValue) != 0 {
+		burst, err = strconv.Atoi(burstValue)
+		if err != nil || burst < 1 {
+			return nil, errors.Errorf("invalid rate limit %q: burst must be a positive integer", value)
+		}
+	}
+
+	return NewRateLimiter(rate, burst), nil
+}
+
+// Wait blocks until a request is allowed or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+	l.last = now
+	l.tokens--
+	wait := time.Duration(0)
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	if err := sleep(ctx, wait); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
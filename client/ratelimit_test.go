@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	for _, fixture := range []struct {
+		value string
+		rate  float64
+		burst int
+		valid bool
+	}{
+		{"5", 5, 1, true},
+		{"0.5", 0.5, 1, true},
+		{"5:10", 5, 10, true},
+		{"0", 0, 0, false},
+		{"-1", 0, 0, false},
+		{"five", 0, 0, false},
+		{"5:0", 0, 0, false},
+		{"5:ten", 0, 0, false},
+	} {
+		limiter, err := ParseRateLimit(fixture.value)
+
+		if !fixture.valid {
+			if err == nil {
+				t.Fatalf("%q is accepted, but want rejected", fixture.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q is rejected: %s", fixture.value, err)
+		}
+		if limiter.rate != fixture.rate || limiter.burst != fixture.burst {
+			t.Fatalf("Got %v:%d, but Want %v:%d", limiter.rate, limiter.burst, fixture.rate, fixture.burst)
+		}
+	}
+
+	if limiter, err := ParseRateLimit(""); limiter != nil || err != nil {
+		t.Fatalf("Got %v, %v, but Want no limit", limiter, err)
+	}
+}
+
+func TestRateLimiter__concurrent(t *testing.T) {
+
+	limiter := NewRateLimiter(50, 2)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := limiter.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 2 requests pass by the burst, the rest 4 wait 20ms each.
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Fatalf("6 requests passed in %s, but want at least 80ms", elapsed)
+	}
+}
+
+func TestRateLimiter__canceled(t *testing.T) {
+
+	limiter := NewRateLimiter(0.1, 1)
+	limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Got %v, but Want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,198 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+
+	"github.com/ohtomi/scrapbox/client/syntax"
+)
+
+// HTMLContent is given to the page template. Body is the rendered document.
+type HTMLContent struct {
+	*Page
+	Body template.HTML
+}
+
+// DefaultHTMLTemplate is used if the page template is not given.
+var DefaultHTMLTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{.Body}}
+</body>
+</html>
+`))
+
+// HTML renders the page into the standalone html with the template, or DefaultHTMLTemplate if nil.
+// The indented lines are the nested list items, and the internal links refer to
+// the pages archived as the html files by the escaped titles.
+func HTML(page *Page, tmpl *template.Template) (string, error) {
+
+	if tmpl == nil {
+		tmpl = DefaultHTMLTemplate
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, &HTMLContent{Page: page, Body: template.HTML(HTMLBody(page))}); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// HTMLBody renders the document of the page into the html fragment.
+func HTMLBody(page *Page) string {
+
+	buf := new(bytes.Buffer)
+
+	// indents of the lists opened, each having the list item opened
+	lists := []int{}
+	closeLists := func(indent int) {
+		for len(lists) != 0 && lists[len(lists)-1] > indent {
+			buf.WriteString("</li>\n</ul>\n")
+			lists = lists[:len(lists)-1]
+		}
+	}
+
+	for _, line := range page.Document.Lines {
+		if len(line.Nodes) == 0 {
+			closeLists(0)
+			continue
+		}
+
+		if line.Indent == 0 {
+			closeLists(0)
+			buf.WriteString(htmlLine(page, line))
+			buf.WriteString("\n")
+			continue
+		}
+
+		closeLists(line.Indent)
+		if len(lists) != 0 && lists[len(lists)-1] == line.Indent {
+			buf.WriteString("</li>\n<li>")
+		} else {
+			buf.WriteString("<ul>\n<li>")
+			lists = append(lists, line.Indent)
+		}
+		buf.WriteString(htmlLine(page, line))
+	}
+	closeLists(0)
+
+	return buf.String()
+}
+
+func htmlLine(page *Page, line *syntax.Line) string {
+
+	if len(line.Nodes) == 1 {
+		switch node := line.Nodes[0].(type) {
+		case *syntax.CodeBlock:
+			return htmlCodeBlock(node)
+		case *syntax.Table:
+			return htmlTable(node)
+		}
+	}
+
+	if level := headingLevel(line); level != 0 {
+		decoration := line.Nodes[0].(*syntax.Decoration)
+		return fmt.Sprintf("<h%d>%s</h%d>", level, htmlInline(page, decoration.Nodes), level)
+	}
+
+	text := htmlInline(page, line.Nodes)
+	if line.Quote {
+		return "<blockquote>" + strings.TrimLeft(text, " \t") + "</blockquote>"
+	}
+	if line.Indent == 0 {
+		return "<p>" + text + "</p>"
+	}
+	return text
+}
+
+func htmlInline(page *Page, nodes []syntax.Node) string {
+
+	texts := []string{}
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case *syntax.PlainText:
+			texts = append(texts, html.EscapeString(node.Text))
+		case *syntax.InternalLink:
+			texts = append(texts, htmlLink(page.pageFile(node.Project, node.Page, ".html"), html.EscapeString(linkLabel(node))))
+		case *syntax.ExternalLink:
+			label := node.Label
+			if len(label) == 0 {
+				label = node.URL
+			}
+			texts = append(texts, htmlLink(node.URL, html.EscapeString(label)))
+		case *syntax.Image:
+			image := fmt.Sprintf(`<img src="%s">`, html.EscapeString(node.URL))
+			if len(node.Link) != 0 {
+				image = htmlLink(node.Link, image)
+			}
+			texts = append(texts, image)
+		case *syntax.Icon:
+			texts = append(texts, fmt.Sprintf(`<img class="icon" src="%s" alt="%s">`, html.EscapeString(page.iconURL(node)), html.EscapeString(node.Page)))
+		case *syntax.Hashtag:
+			texts = append(texts, htmlLink(page.pageFile("", node.Tag, ".html"), html.EscapeString("#"+node.Tag)))
+		case *syntax.Math:
+			texts = append(texts, `<span class="math">`+html.EscapeString(node.Formula)+"</span>")
+		case *syntax.Decoration:
+			text := htmlInline(page, node.Nodes)
+			if node.Underline {
+				text = "<u>" + text + "</u>"
+			}
+			if node.Strike {
+				text = "<s>" + text + "</s>"
+			}
+			if node.Italic {
+				text = "<em>" + text + "</em>"
+			}
+			if node.Bold {
+				text = "<strong>" + text + "</strong>"
+			}
+			texts = append(texts, text)
+		case *syntax.CodeSpan:
+			texts = append(texts, "<code>"+html.EscapeString(node.Code)+"</code>")
+		case *syntax.CodeBlock:
+			texts = append(texts, htmlCodeBlock(node))
+		case *syntax.Table:
+			texts = append(texts, htmlTable(node))
+		}
+	}
+
+	return strings.Join(texts, "")
+}
+
+func htmlLink(url, label string) string {
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), label)
+}
+
+func htmlCodeBlock(block *syntax.CodeBlock) string {
+	return fmt.Sprintf(`<pre><code class="language-%s">%s</code></pre>`, html.EscapeString(block.Language), html.EscapeString(block.Content))
+}
+
+// htmlTable renders the first row as the header.
+func htmlTable(table *syntax.Table) string {
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("<table>\n")
+	for i, row := range table.Rows {
+		tag := "td"
+		if i == 0 {
+			tag = "th"
+		}
+		buf.WriteString("<tr>")
+		for _, cell := range row {
+			fmt.Fprintf(buf, "<%s>%s</%s>", tag, html.EscapeString(cell), tag)
+		}
+		buf.WriteString("</tr>\n")
+	}
+	buf.WriteString("</table>")
+
+	return buf.String()
+}
@@ -0,0 +1,92 @@
+package render
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/ohtomi/scrapbox/client/syntax"
+)
+
+func TestRenderHTML__inline(t *testing.T) {
+	for _, fixture := range []struct {
+		node     syntax.Node
+		expected string
+	}{
+		{&syntax.PlainText{Text: "1 < 2 & \"3\""}, "<p>1 &lt; 2 &amp; &#34;3&#34;</p>\n"},
+		{&syntax.InternalLink{Page: "title having whitespaces"}, "<p><a href=\"title%20having%20whitespaces.html\">title having whitespaces</a></p>\n"},
+		{&syntax.InternalLink{Project: "help-jp"}, "<p><a href=\"https://scrapbox.io/help-jp/\">/help-jp</a></p>\n"},
+		{&syntax.ExternalLink{URL: "https://www.google.com/?q=a&b", Label: "Google"}, "<p><a href=\"https://www.google.com/?q=a&amp;b\">Google</a></p>\n"},
+		{&syntax.Image{URL: "https://gyazo.com/1678258/avatar", Link: "https://github.com/ohtomi"}, "<p><a href=\"https://github.com/ohtomi\"><img src=\"https://gyazo.com/1678258/avatar\"></a></p>\n"},
+		{&syntax.Icon{Project: "help-jp", Page: "scrapbox"}, "<p><img class=\"icon\" src=\"https://scrapbox.io/api/pages/help-jp/scrapbox/icon\" alt=\"scrapbox\"></p>\n"},
+		{&syntax.Hashtag{Tag: "no-url"}, "<p><a href=\"no-url.html\">#no-url</a></p>\n"},
+		{&syntax.Math{Formula: "1 < 2"}, "<p><span class=\"math\">1 &lt; 2</span></p>\n"},
+		{&syntax.Decoration{Bold: true, Underline: true, Level: 1, Nodes: []syntax.Node{&syntax.PlainText{Text: "scrapbox"}}}, "<p><strong><u>scrapbox</u></strong></p>\n"},
+		{&syntax.CodeSpan{Code: "<br>"}, "<p><code>&lt;br&gt;</code></p>\n"},
+	} {
+		page := &Page{Project: "go-scrapbox", Document: &syntax.Document{
+			Lines: []*syntax.Line{{Nodes: []syntax.Node{fixture.node}}},
+		}}
+
+		assertEqualTo(t, HTMLBody(page), fixture.expected)
+	}
+}
+
+func TestRenderHTML__blocks(t *testing.T) {
+	page := &Page{
+		Project: "go-scrapbox",
+		Title:   "title having <tag>",
+		Document: &syntax.Document{
+			Lines: []*syntax.Line{
+				{Nodes: []syntax.Node{&syntax.Decoration{Bold: true, Level: 2, Nodes: []syntax.Node{&syntax.PlainText{Text: "heading"}}}}},
+				{Indent: 1, Nodes: []syntax.Node{&syntax.PlainText{Text: "item 1"}}},
+				{Indent: 2, Nodes: []syntax.Node{&syntax.CodeBlock{Filename: "sample.js", Language: "js", Content: "if (a < b) {}"}}},
+				{Indent: 1, Quote: true, Nodes: []syntax.Node{&syntax.PlainText{Text: "item 2"}}},
+				{Nodes: []syntax.Node{&syntax.Table{Name: "sample", Rows: [][]string{{"a", "b"}, {"1", "2"}}}}},
+			},
+		},
+	}
+
+	expected := "<h3>heading</h3>\n" +
+		"<ul>\n" +
+		"<li>item 1<ul>\n" +
+		"<li><pre><code class=\"language-js\">if (a &lt; b) {}</code></pre></li>\n" +
+		"</ul>\n" +
+		"</li>\n" +
+		"<li><blockquote>item 2</blockquote></li>\n" +
+		"</ul>\n" +
+		"<table>\n" +
+		"<tr><th>a</th><th>b</th></tr>\n" +
+		"<tr><td>1</td><td>2</td></tr>\n" +
+		"</table>\n"
+
+	assertEqualTo(t, HTMLBody(page), expected)
+
+	tmpl := template.Must(template.New("page").Parse("<title>{{.Project}} - {{.Title}}</title>\n{{.Body}}"))
+	actual, err := HTML(page, tmpl)
+	if err != nil {
+		t.Fatalf("Failed to render: %s", err)
+	}
+
+	assertEqualTo(t, actual, "<title>go-scrapbox - title having &lt;tag&gt;</title>\n"+expected)
+}
+
+func TestRenderHTML__parsed(t *testing.T) {
+	page := NewPage("", "go-scrapbox", []string{
+		"sample",
+		"plain #tag and [link]",
+		" item with [label https://example.com] and `code`",
+		"  > quoted [/help-jp/link]",
+		"> see #tag",
+	})
+
+	expected := "<p>plain <a href=\"tag.html\">#tag</a> and <a href=\"link.html\">link</a></p>\n" +
+		"<ul>\n" +
+		"<li>item with <a href=\"https://example.com\">label</a> and <code>code</code><ul>\n" +
+		"<li><blockquote>quoted <a href=\"../help-jp/link.html\">/help-jp/link</a></blockquote></li>\n" +
+		"</ul>\n" +
+		"</li>\n" +
+		"</ul>\n" +
+		"<blockquote>see <a href=\"tag.html\">#tag</a></blockquote>\n"
+
+	assertEqualTo(t, HTMLBody(page), expected)
+}
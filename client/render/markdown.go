@@ -0,0 +1,209 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ohtomi/scrapbox/client/syntax"
+)
+
+var (
+	markdownEscaper = strings.NewReplacer(
+		"\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "~", "\\~",
+		"[", "\\[", "]", "\\]", "<", "\\<", ">", "\\>", "$", "\\$")
+	markdownURLEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29")
+	// the text starting with 1. or 1) becomes the ordered list item unless escaped
+	markdownListMarker = regexp.MustCompile("^([0-9]+)([.)])")
+)
+
+// Markdown renders the page into GitHub Flavored Markdown.
+// The indented lines are the nested list items, and the internal links refer to
+// the pages archived as the markdown files by the escaped titles.
+func Markdown(page *Page) string {
+
+	blocks := []string{}
+	if len(page.Title) != 0 {
+		blocks = append(blocks, "# "+escapeMarkdown(page.Title))
+	}
+
+	listed := false
+	for _, line := range page.Document.Lines {
+		if len(line.Nodes) == 0 {
+			listed = false
+			continue
+		}
+
+		text, block := markdownLine(page, line)
+		if listed && line.Indent > 0 && !block {
+			blocks[len(blocks)-1] += "\n" + text
+		} else {
+			blocks = append(blocks, text)
+		}
+		listed = line.Indent > 0
+	}
+
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// markdownLine returns the line rendered, and whether the line is the code block or the table.
+func markdownLine(page *Page, line *syntax.Line) (string, bool) {
+
+	if len(line.Nodes) == 1 {
+		var block string
+		switch node := line.Nodes[0].(type) {
+		case *syntax.CodeBlock:
+			block = markdownCodeBlock(node)
+		case *syntax.Table:
+			block = markdownTable(node)
+		}
+		if len(block) != 0 {
+			// indent the block as the content of the list item
+			indent := strings.Repeat("  ", line.Indent)
+			return indent + strings.Replace(block, "\n", "\n"+indent, -1), true
+		}
+	}
+
+	if level := headingLevel(line); level != 0 {
+		decoration := line.Nodes[0].(*syntax.Decoration)
+		return strings.Repeat("#", level) + " " + markdownInline(page, decoration.Nodes), false
+	}
+
+	// the leading whitespaces, such as the one following '>', would make the code block
+	text := strings.TrimLeft(markdownInline(page, line.Nodes), " \t")
+	text = markdownListMarker.ReplaceAllString(text, "$1\\$2")
+	if strings.HasPrefix(text, "#") || strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		text = "\\" + text
+	}
+	if line.Quote {
+		text = "> " + text
+	}
+	if line.Indent > 0 {
+		return strings.Repeat("  ", line.Indent-1) + "- " + text, false
+	}
+	return text, false
+}
+
+func markdownInline(page *Page, nodes []syntax.Node) string {
+
+	texts := []string{}
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case *syntax.PlainText:
+			texts = append(texts, escapeMarkdown(node.Text))
+		case *syntax.InternalLink:
+			texts = append(texts, markdownLink(escapeMarkdown(linkLabel(node)), page.pageFile(node.Project, node.Page, ".md")))
+		case *syntax.ExternalLink:
+			if len(node.Label) == 0 {
+				texts = append(texts, "<"+node.URL+">")
+			} else {
+				texts = append(texts, markdownLink(escapeMarkdown(node.Label), node.URL))
+			}
+		case *syntax.Image:
+			image := fmt.Sprintf("![](%s)", markdownURLEscaper.Replace(node.URL))
+			if len(node.Link) != 0 {
+				image = markdownLink(image, node.Link)
+			}
+			texts = append(texts, image)
+		case *syntax.Icon:
+			texts = append(texts, fmt.Sprintf("![%s](%s)", escapeMarkdown(node.Page), markdownURLEscaper.Replace(page.iconURL(node))))
+		case *syntax.Hashtag:
+			texts = append(texts, markdownLink(escapeMarkdown("#"+node.Tag), page.pageFile("", node.Tag, ".md")))
+		case *syntax.Math:
+			texts = append(texts, "$"+node.Formula+"$")
+		case *syntax.Decoration:
+			text := markdownInline(page, node.Nodes)
+			if node.Underline {
+				text = "<u>" + text + "</u>"
+			}
+			if node.Strike {
+				text = "~~" + text + "~~"
+			}
+			if node.Italic {
+				text = "_" + text + "_"
+			}
+			if node.Bold {
+				text = "**" + text + "**"
+			}
+			texts = append(texts, text)
+		case *syntax.CodeSpan:
+			texts = append(texts, markdownCodeSpan(node.Code))
+		case *syntax.CodeBlock:
+			texts = append(texts, markdownCodeSpan(node.Content))
+		case *syntax.Table:
+			texts = append(texts, escapeMarkdown(node.Name))
+		}
+	}
+
+	return strings.Join(texts, "")
+}
+
+func markdownLink(label, url string) string {
+	return fmt.Sprintf("[%s](%s)", label, markdownURLEscaper.Replace(url))
+}
+
+// markdownCodeSpan encloses the code with the backticks more than the ones in the code.
+func markdownCodeSpan(code string) string {
+	fence := strings.Repeat("`", longestRun(code, '`')+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+func markdownCodeBlock(block *syntax.CodeBlock) string {
+	fence := strings.Repeat("`", 3)
+	if run := longestRun(block.Content, '`'); run >= 3 {
+		fence = strings.Repeat("`", run+1)
+	}
+	return fmt.Sprintf("%s%s\n%s\n%s", fence, block.Language, block.Content, fence)
+}
+
+// markdownTable renders the first row as the header.
+func markdownTable(table *syntax.Table) string {
+
+	if len(table.Rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range table.Rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	tableRow := func(cells []string) string {
+		escaped := make([]string, columns)
+		for i, cell := range cells {
+			escaped[i] = strings.Replace(escapeMarkdown(cell), "|", "\\|", -1)
+		}
+		return "| " + strings.Join(escaped, " | ") + " |"
+	}
+
+	lines := []string{tableRow(table.Rows[0]), "|" + strings.Repeat(" --- |", columns)}
+	for _, row := range table.Rows[1:] {
+		lines = append(lines, tableRow(row))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+func longestRun(text string, c rune) int {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == c {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
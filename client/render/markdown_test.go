@@ -0,0 +1,108 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ohtomi/scrapbox/client/syntax"
+)
+
+func TestRenderMarkdown__inline(t *testing.T) {
+	for _, fixture := range []struct {
+		node     syntax.Node
+		expected string
+	}{
+		{&syntax.PlainText{Text: "1 * 2 = [2]"}, "1 \\* 2 = \\[2\\]\n"},
+		{&syntax.InternalLink{Page: "title having whitespaces"}, "[title having whitespaces](title%20having%20whitespaces.md)\n"},
+		{&syntax.InternalLink{Project: "go-scrapbox", Page: "title having slash / mark"}, "[/go-scrapbox/title having slash / mark](title%20having%20slash%20%2F%20mark.md)\n"},
+		{&syntax.InternalLink{Project: "help-jp", Page: "ブラケティング"}, "[/help-jp/ブラケティング](../help-jp/%E3%83%96%E3%83%A9%E3%82%B1%E3%83%86%E3%82%A3%E3%83%B3%E3%82%B0.md)\n"},
+		{&syntax.ExternalLink{URL: "https://www.google.co.jp"}, "<https://www.google.co.jp>\n"},
+		{&syntax.ExternalLink{URL: "https://www.google.com", Label: "Google"}, "[Google](https://www.google.com)\n"},
+		{&syntax.Image{URL: "https://gyazo.com/1678258/avatar", Link: "https://github.com/ohtomi"}, "[![](https://gyazo.com/1678258/avatar)](https://github.com/ohtomi)\n"},
+		{&syntax.Icon{Page: "ohtomi"}, "![ohtomi](https://scrapbox.io/api/pages/go-scrapbox/ohtomi/icon)\n"},
+		{&syntax.Hashtag{Tag: "no-url"}, "[#no-url](no-url.md)\n"},
+		{&syntax.Math{Formula: "1+2 = 3"}, "$1+2 = 3$\n"},
+		{&syntax.Decoration{Bold: true, Italic: true, Strike: true, Level: 1, Nodes: []syntax.Node{&syntax.PlainText{Text: "scrapbox"}}}, "**_~~scrapbox~~_**\n"},
+		{&syntax.CodeSpan{Code: "a `b` c"}, "``a `b` c``\n"},
+	} {
+		page := &Page{Project: "go-scrapbox", Document: &syntax.Document{
+			Lines: []*syntax.Line{{Nodes: []syntax.Node{fixture.node}}},
+		}}
+
+		assertEqualTo(t, Markdown(page), fixture.expected)
+	}
+}
+
+func TestRenderMarkdown__blocks(t *testing.T) {
+	page := &Page{
+		Project: "go-scrapbox",
+		Title:   "title having paren ( ) mark",
+		Document: &syntax.Document{
+			Lines: []*syntax.Line{
+				{Nodes: []syntax.Node{&syntax.Decoration{Bold: true, Level: 3, Nodes: []syntax.Node{&syntax.PlainText{Text: "heading"}}}}},
+				{Nodes: []syntax.Node{&syntax.PlainText{Text: "# not heading"}}},
+				{Indent: 1, Nodes: []syntax.Node{&syntax.PlainText{Text: "item 1"}}},
+				{Indent: 2, Nodes: []syntax.Node{&syntax.PlainText{Text: "item 1-1"}}},
+				{Indent: 1, Quote: true, Nodes: []syntax.Node{&syntax.PlainText{Text: "item 2"}}},
+				{Indent: 2, Nodes: []syntax.Node{&syntax.CodeBlock{Filename: "sample.js", Language: "js", Content: "function hello() {\n\treturn 'hello'\n}"}}},
+				{Nodes: []syntax.Node{}},
+				{Nodes: []syntax.Node{&syntax.Table{Name: "sample", Rows: [][]string{{"a", "b|c"}, {"1"}}}}},
+			},
+		},
+	}
+
+	expected := "# title having paren ( ) mark\n" +
+		"\n" +
+		"## heading\n" +
+		"\n" +
+		"\\# not heading\n" +
+		"\n" +
+		"- item 1\n" +
+		"  - item 1-1\n" +
+		"- > item 2\n" +
+		"\n" +
+		"    ```js\n" +
+		"    function hello() {\n" +
+		"    \treturn 'hello'\n" +
+		"    }\n" +
+		"    ```\n" +
+		"\n" +
+		"| a | b\\|c |\n" +
+		"| --- | --- |\n" +
+		"| 1 |  |\n"
+
+	assertEqualTo(t, Markdown(page), expected)
+}
+
+func TestRenderMarkdown__parsed(t *testing.T) {
+	page := NewPage("", "go-scrapbox", []string{
+		"sample",
+		"plain #tag and [link] and [label https://example.com]",
+		" item with `code` after [[bold]] and [link]",
+		"  > quoted #tag",
+		"1. not ordered",
+		"- not listed",
+		"> - not listed in quote",
+	})
+
+	expected := "# sample\n" +
+		"\n" +
+		"plain [#tag](tag.md) and [link](link.md) and [label](https://example.com)\n" +
+		"\n" +
+		"- item with `code` after **bold** and [link](link.md)\n" +
+		"  - > quoted [#tag](tag.md)\n" +
+		"\n" +
+		"1\\. not ordered\n" +
+		"\n" +
+		"\\- not listed\n" +
+		"\n" +
+		"> \\- not listed in quote\n"
+
+	assertEqualTo(t, Markdown(page), expected)
+}
+
+func assertEqualTo(t *testing.T, actual, expected interface{}) {
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Got %+v, but Want %+v", actual, expected)
+	}
+}
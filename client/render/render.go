@@ -0,0 +1,92 @@
+package render
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ohtomi/scrapbox/client"
+	"github.com/ohtomi/scrapbox/client/syntax"
+)
+
+// Page is the scrapbox page rendered by the renderers.
+// Host and Project are used to resolve the links and the icons.
+type Page struct {
+	Host     string
+	Project  string
+	Title    string
+	Document *syntax.Document
+}
+
+// NewPage parses the lines of the page. The first line is the title.
+func NewPage(host, project string, lines []string) *Page {
+
+	page := &Page{Host: host, Project: project}
+	if len(lines) != 0 {
+		page.Title, lines = lines[0], lines[1:]
+	}
+	page.Document = syntax.Parse([]byte(strings.Join(lines, "\n")), false)
+
+	return page
+}
+
+func (p *Page) host() string {
+	if len(p.Host) == 0 {
+		return client.DefaultHost
+	}
+	return p.Host
+}
+
+// pageURL returns the url of the page on scrapbox. The empty page means the top of the project.
+func (p *Page) pageURL(project, page string) string {
+	if len(project) == 0 {
+		project = p.Project
+	}
+	return client.GetURL(p.host(), project, page)
+}
+
+func (p *Page) iconURL(icon *syntax.Icon) string {
+	project := icon.Project
+	if len(project) == 0 {
+		project = p.Project
+	}
+	return client.GetIconURL(p.host(), project, icon.Page)
+}
+
+// pageFile returns the relative path to the page archived as the file having the extension.
+// The pages of the other projects are in the sibling directories.
+func (p *Page) pageFile(project, page, ext string) string {
+	if len(project) == 0 || project == p.Project {
+		return url.PathEscape(page) + ext
+	}
+	if len(page) == 0 {
+		return p.pageURL(project, page)
+	}
+	return fmt.Sprintf("../%s/%s%s", url.PathEscape(project), url.PathEscape(page), ext)
+}
+
+func linkLabel(link *syntax.InternalLink) string {
+	if len(link.Project) == 0 {
+		return link.Page
+	}
+	if len(link.Page) == 0 {
+		return "/" + link.Project
+	}
+	return fmt.Sprintf("/%s/%s", link.Project, link.Page)
+}
+
+// headingLevel returns the level of the heading, 2 for [*** text] and 3 for [** text],
+// or 0 if the line is not the heading.
+func headingLevel(line *syntax.Line) int {
+	if line.Indent != 0 || line.Quote || len(line.Nodes) != 1 {
+		return 0
+	}
+	decoration, ok := line.Nodes[0].(*syntax.Decoration)
+	if !ok || decoration.Level < 2 {
+		return 0
+	}
+	if decoration.Level >= 3 {
+		return 2
+	}
+	return 3
+}
@@ -0,0 +1,236 @@
+package render
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ohtomi/scrapbox/client/syntax"
+)
+
+// ANSI escape sequences to turn on and off the styles.
+const (
+	ansiBold         = "\x1b[1m"
+	ansiDim          = "\x1b[2m"
+	ansiNormal       = "\x1b[22m" // neither bold nor dim
+	ansiItalic       = "\x1b[3m"
+	ansiItalicOff    = "\x1b[23m"
+	ansiUnderline    = "\x1b[4m"
+	ansiUnderlineOff = "\x1b[24m"
+	ansiStrike       = "\x1b[9m"
+	ansiStrikeOff    = "\x1b[29m"
+	ansiRed          = "\x1b[31m"
+	ansiGreen        = "\x1b[32m"
+	ansiYellow       = "\x1b[33m"
+	ansiBlue         = "\x1b[34m"
+	ansiMagenta      = "\x1b[35m"
+	ansiCyan         = "\x1b[36m"
+	ansiColorOff     = "\x1b[39m"
+)
+
+var bullets = []string{"•", "◦", "▪"}
+
+// Terminal renders the page for the terminal. The indented lines are the bulleted items,
+// and the code blocks and the tables are boxed. The styles and the colors are rendered
+// with ANSI escape sequences if colored.
+func Terminal(page *Page, colored bool) string {
+
+	t := &terminal{colored: colored}
+
+	lines := []string{}
+	if len(page.Title) != 0 {
+		lines = append(lines, t.style(page.Title, ansiBold+ansiUnderline, ansiUnderlineOff+ansiNormal))
+	}
+
+	for _, line := range page.Document.Lines {
+		lines = append(lines, t.line(line)...)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+type terminal struct {
+	colored bool
+	// styles enclosing the text being rendered, turned on again after the inner styles are turned off
+	outer []string
+}
+
+func (t *terminal) style(text, on, off string) string {
+	if !t.colored || len(on) == 0 || len(text) == 0 {
+		return text
+	}
+	return on + text + off + strings.Join(t.outer, "")
+}
+
+// nest renders the nodes in the style, which is kept through the styles of the nodes.
+func (t *terminal) nest(nodes []syntax.Node, on, off string) string {
+	t.outer = append(t.outer, on)
+	text := t.inline(nodes)
+	t.outer = t.outer[:len(t.outer)-1]
+	return t.style(text, on, off)
+}
+
+func (t *terminal) line(line *syntax.Line) []string {
+
+	if len(line.Nodes) == 1 {
+		var box []string
+		switch node := line.Nodes[0].(type) {
+		case *syntax.CodeBlock:
+			box = t.box(node.Filename, strings.Split(node.Content, "\n"), ansiGreen)
+		case *syntax.Table:
+			box = t.box(node.Name, alignColumns(node.Rows), "")
+		}
+		if box != nil {
+			// indent the box as the content of the bulleted item
+			indent := strings.Repeat("  ", line.Indent)
+			for i := range box {
+				box[i] = indent + box[i]
+			}
+			return box
+		}
+	}
+
+	if level := headingLevel(line); level != 0 {
+		decoration := line.Nodes[0].(*syntax.Decoration)
+		return []string{t.nest(decoration.Nodes, ansiBold, ansiNormal)}
+	}
+
+	text := t.inline(line.Nodes)
+	if line.Quote {
+		text = t.style("│", ansiDim, ansiNormal) + " " + text
+	}
+	if line.Indent > 0 {
+		text = strings.Repeat("  ", line.Indent-1) + t.style(bullets[(line.Indent-1)%len(bullets)], ansiCyan, ansiColorOff) + " " + text
+	}
+
+	return []string{text}
+}
+
+// box encloses the lines with the box drawing characters on the left side, so that
+// the lines of any width are boxed.
+func (t *terminal) box(label string, lines []string, color string) []string {
+
+	border := func(s string) string {
+		return t.style(s, ansiDim, ansiNormal)
+	}
+
+	boxed := []string{border("┌ ") + t.style(label, ansiBold, ansiNormal)}
+	for _, line := range lines {
+		boxed = append(boxed, border("│ ")+t.style(line, color, ansiColorOff))
+	}
+	boxed = append(boxed, border("└"))
+
+	return boxed
+}
+
+func (t *terminal) link(text string) string {
+	return t.style(text, ansiUnderline+ansiBlue, ansiColorOff+ansiUnderlineOff)
+}
+
+func (t *terminal) inline(nodes []syntax.Node) string {
+
+	texts := []string{}
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case *syntax.PlainText:
+			texts = append(texts, node.Text)
+		case *syntax.InternalLink:
+			texts = append(texts, t.link(linkLabel(node)))
+		case *syntax.ExternalLink:
+			if len(node.Label) == 0 {
+				texts = append(texts, t.link(node.URL))
+			} else {
+				texts = append(texts, t.link(node.Label)+" "+t.style("("+node.URL+")", ansiDim, ansiNormal))
+			}
+		case *syntax.Image:
+			url := node.URL
+			if len(node.Link) != 0 {
+				url = node.Link
+			}
+			texts = append(texts, t.link(url))
+		case *syntax.Icon:
+			texts = append(texts, t.style("("+node.Page+")", ansiYellow, ansiColorOff))
+		case *syntax.Hashtag:
+			texts = append(texts, t.style("#"+node.Tag, ansiMagenta, ansiColorOff))
+		case *syntax.Math:
+			texts = append(texts, t.style(node.Formula, ansiCyan, ansiColorOff))
+		case *syntax.Decoration:
+			on, off := "", ""
+			if node.Underline {
+				on, off = ansiUnderline+on, off+ansiUnderlineOff
+			}
+			if node.Strike {
+				on, off = ansiStrike+on, off+ansiStrikeOff
+			}
+			if node.Italic {
+				on, off = ansiItalic+on, off+ansiItalicOff
+			}
+			if node.Bold {
+				on, off = ansiBold+on, off+ansiNormal
+			}
+			texts = append(texts, t.nest(node.Nodes, on, off))
+		case *syntax.CodeSpan:
+			texts = append(texts, t.style(node.Code, ansiRed, ansiColorOff))
+		case *syntax.CodeBlock:
+			texts = append(texts, t.style(node.Content, ansiGreen, ansiColorOff))
+		case *syntax.Table:
+			texts = append(texts, node.Name)
+		}
+	}
+
+	return strings.Join(texts, "")
+}
+
+// alignColumns pads the cells so that the columns are aligned.
+func alignColumns(rows [][]string) []string {
+
+	widths := []int{}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := displayWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	lines := []string{}
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell
+			if i != len(row)-1 {
+				cells[i] += strings.Repeat(" ", widths[i]-displayWidth(cell))
+			}
+		}
+		lines = append(lines, strings.Join(cells, "  "))
+	}
+
+	return lines
+}
+
+// displayWidth counts the wide characters of east asian scripts as 2 columns.
+func displayWidth(text string) int {
+
+	width := 0
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf:
+			width++
+		case r >= 0x1100 && r <= 0x115f, // Hangul Jamo
+			r >= 0x2e80 && r <= 0xa4cf, // CJK, Hiragana, Katakana
+			r >= 0xac00 && r <= 0xd7a3, // Hangul Syllables
+			r >= 0xf900 && r <= 0xfaff, // CJK Compatibility Ideographs
+			r >= 0xfe30 && r <= 0xfe4f, // CJK Compatibility Forms
+			r >= 0xff00 && r <= 0xff60, // Fullwidth Forms
+			r >= 0xffe0 && r <= 0xffe6,
+			r >= 0x20000 && r <= 0x3fffd:
+			width += 2
+		default:
+			width++
+		}
+	}
+
+	return width
+}
@@ -0,0 +1,85 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/ohtomi/scrapbox/client/syntax"
+)
+
+func TestRenderTerminal(t *testing.T) {
+	page := &Page{
+		Project: "go-scrapbox",
+		Title:   "title having paren ( ) mark",
+		Document: &syntax.Document{
+			Lines: []*syntax.Line{
+				{Nodes: []syntax.Node{&syntax.PlainText{Text: "see "}, &syntax.ExternalLink{URL: "https://www.google.com", Label: "Google"}}},
+				{Indent: 1, Nodes: []syntax.Node{&syntax.Decoration{Bold: true, Strike: true, Level: 1, Nodes: []syntax.Node{&syntax.PlainText{Text: "item 1"}}}}},
+				{Indent: 2, Quote: true, Nodes: []syntax.Node{&syntax.Hashtag{Tag: "english"}}},
+				{Indent: 1, Nodes: []syntax.Node{&syntax.CodeBlock{Filename: "sample.js", Language: "js", Content: "return 1"}}},
+				{Nodes: []syntax.Node{}},
+				{Nodes: []syntax.Node{&syntax.Table{Name: "sample", Rows: [][]string{{"日本語", "b"}, {"1", "2"}}}}},
+			},
+		},
+	}
+
+	assertEqualTo(t, Terminal(page, false), "title having paren ( ) mark\n"+
+		"see Google (https://www.google.com)\n"+
+		"• item 1\n"+
+		"  ◦ │ #english\n"+
+		"  ┌ sample.js\n"+
+		"  │ return 1\n"+
+		"  └\n"+
+		"\n"+
+		"┌ sample\n"+
+		"│ 日本語  b\n"+
+		"│ 1       2\n"+
+		"└\n")
+
+	assertEqualTo(t, Terminal(page, true), "\x1b[1m\x1b[4mtitle having paren ( ) mark\x1b[24m\x1b[22m\n"+
+		"see \x1b[4m\x1b[34mGoogle\x1b[39m\x1b[24m \x1b[2m(https://www.google.com)\x1b[22m\n"+
+		"\x1b[36m•\x1b[39m \x1b[1m\x1b[9mitem 1\x1b[29m\x1b[22m\n"+
+		"  \x1b[36m◦\x1b[39m \x1b[2m│\x1b[22m \x1b[35m#english\x1b[39m\n"+
+		"  \x1b[2m┌ \x1b[22m\x1b[1msample.js\x1b[22m\n"+
+		"  \x1b[2m│ \x1b[22m\x1b[32mreturn 1\x1b[39m\n"+
+		"  \x1b[2m└\x1b[22m\n"+
+		"\n"+
+		"\x1b[2m┌ \x1b[22m\x1b[1msample\x1b[22m\n"+
+		"\x1b[2m│ \x1b[22m日本語  b\n"+
+		"\x1b[2m│ \x1b[22m1       2\n"+
+		"\x1b[2m└\x1b[22m\n")
+}
+
+func TestRenderTerminal__nested_styles(t *testing.T) {
+	page := &Page{
+		Project: "go-scrapbox",
+		Document: &syntax.Document{
+			Lines: []*syntax.Line{
+				{Nodes: []syntax.Node{&syntax.Decoration{Bold: true, Level: 2, Nodes: []syntax.Node{
+					&syntax.PlainText{Text: "see "}, &syntax.ExternalLink{URL: "https://www.google.com", Label: "Google"}, &syntax.PlainText{Text: " now"},
+				}}}},
+				{Nodes: []syntax.Node{&syntax.Decoration{Underline: true, Nodes: []syntax.Node{
+					&syntax.PlainText{Text: "a "}, &syntax.InternalLink{Page: "b"}, &syntax.PlainText{Text: " c"},
+				}}}},
+			},
+		},
+	}
+
+	assertEqualTo(t, Terminal(page, true), "\x1b[1msee \x1b[4m\x1b[34mGoogle\x1b[39m\x1b[24m\x1b[1m \x1b[2m(https://www.google.com)\x1b[22m\x1b[1m now\x1b[22m\n"+
+		"\x1b[4ma \x1b[4m\x1b[34mb\x1b[39m\x1b[24m\x1b[4m c\x1b[24m\n")
+}
+
+func TestRenderTerminal__parsed(t *testing.T) {
+	page := NewPage("", "go-scrapbox", []string{
+		"sample",
+		"plain #tag and [link]",
+		" item with [[bold]] and `code`",
+	})
+
+	assertEqualTo(t, Terminal(page, false), "sample\n"+
+		"plain #tag and link\n"+
+		"• item with bold and code\n")
+
+	assertEqualTo(t, Terminal(page, true), "\x1b[1m\x1b[4msample\x1b[24m\x1b[22m\n"+
+		"plain \x1b[35m#tag\x1b[39m and \x1b[4m\x1b[34mlink\x1b[39m\x1b[24m\n"+
+		"\x1b[36m•\x1b[39m item with \x1b[1mbold\x1b[22m and \x1b[31mcode\x1b[39m\n")
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+)
+
+type User struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Photo       string `json:"photo"`
+}
+
+type PageSummary struct {
+	ID           string   `json:"id"`
+	Title        string   `json:"title"`
+	Image        string   `json:"image"`
+	Descriptions []string `json:"descriptions"`
+	User         User     `json:"user"`
+	Pin          int64    `json:"pin"`
+	Views        int      `json:"views"`
+	Linked       int      `json:"linked"`
+	Created      int64    `json:"created"`
+	Updated      int64    `json:"updated"`
+	Accessed     int64    `json:"accessed"`
+
+	// search api only
+	Snipet []string `json:"snipet"`
+}
+
+type PageList struct {
+	ProjectName string        `json:"projectName"`
+	Skip        int           `json:"skip"`
+	Limit       int           `json:"limit"`
+	Count       int           `json:"count"`
+	Pages       []PageSummary `json:"pages"`
+}
+
+func (l *PageList) validate() error {
+	if l.Pages == nil {
+		return errors.New("malformed page list response: missing pages")
+	}
+	for i, p := range l.Pages {
+		if len(p.Title) == 0 {
+			return errors.Errorf("malformed page list response: missing title of pages[%d]", i)
+		}
+	}
+	return nil
+}
+
+type PageLine struct {
+	ID      string `json:"id"`
+	Text    string `json:"text"`
+	UserID  string `json:"userId"`
+	Created int64  `json:"created"`
+	Updated int64  `json:"updated"`
+}
+
+type RelatedPage struct {
+	ID           string   `json:"id"`
+	Title        string   `json:"title"`
+	TitleLc      string   `json:"titleLc"`
+	Image        string   `json:"image"`
+	Descriptions []string `json:"descriptions"`
+	LinksLc      []string `json:"linksLc"`
+	Linked       int      `json:"linked"`
+	Updated      int64    `json:"updated"`
+	Accessed     int64    `json:"accessed"`
+
+	// projectLinks1hop only
+	ProjectName string `json:"projectName"`
+}
+
+type RelatedPages struct {
+	Links1hop        []RelatedPage `json:"links1hop"`
+	Links2hop        []RelatedPage `json:"links2hop"`
+	ProjectLinks1hop []RelatedPage `json:"projectLinks1hop"`
+}
+
+type PageDetail struct {
+	PageSummary
+
+	Lines        []PageLine   `json:"lines"`
+	Links        []string     `json:"links"`
+	RelatedPages RelatedPages `json:"relatedPages"`
+}
+
+func (d *PageDetail) validate() error {
+	if len(d.Title) == 0 {
+		return errors.New("malformed page response: missing title")
+	}
+	if d.Lines == nil {
+		return errors.New("malformed page response: missing lines")
+	}
+	return nil
+}
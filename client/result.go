@@ -4,20 +4,158 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 type QueryResult struct {
-	Count int
-	Pages []string
+	Count     int
+	Pages     []string
+	Summaries []PageSummary
 }
 
 type Page struct {
-	Title string
-	Lines []string
-	Links []string
+	ID           string
+	Title        string
+	Image        string
+	Descriptions []string
+	User         User
+	Pin          int64
+	Views        int
+	Linked       int
+	Created      time.Time
+	Updated      time.Time
+	Accessed     time.Time
+	Lines        []string
+	LineDetails  []Line
+	Links        []string
+	Related      RelatedPages
+}
+
+type Line struct {
+	ID      string
+	Text    string
+	UserID  string
+	Created time.Time
+	Updated time.Time
+}
+
+func newPage(v *PageDetail) *Page {
+
+	texts := make([]string, len(v.Lines))
+	lines := make([]Line, len(v.Lines))
+	for i, l := range v.Lines {
+		texts[i] = l.Text
+		lines[i] = Line{
+			ID:      l.ID,
+			Text:    l.Text,
+			UserID:  l.UserID,
+			Created: unixTime(l.Created),
+			Updated: unixTime(l.Updated),
+		}
+	}
+
+	return &Page{
+		ID:           v.ID,
+		Title:        v.Title,
+		Image:        v.Image,
+		Descriptions: v.Descriptions,
+		User:         v.User,
+		Pin:          v.Pin,
+		Views:        v.Views,
+		Linked:       v.Linked,
+		Created:      v.CreatedAt(),
+		Updated:      v.UpdatedAt(),
+		Accessed:     v.AccessedAt(),
+		Lines:        texts,
+		LineDetails:  lines,
+		Links:        v.Links,
+		Related:      v.RelatedPages,
+	}
+}
+
+func (p PageSummary) CreatedAt() time.Time {
+	return unixTime(p.Created)
+}
+
+func (p PageSummary) UpdatedAt() time.Time {
+	return unixTime(p.Updated)
+}
+
+func (p PageSummary) AccessedAt() time.Time {
+	return unixTime(p.Accessed)
+}
+
+type Neighbour struct {
+	Title       string
+	ProjectName string
+	Hops        int
+}
+
+func (n Neighbour) String() string {
+	if len(n.ProjectName) != 0 {
+		return fmt.Sprintf("/%s/%s", n.ProjectName, n.Title)
+	}
+	return n.Title
+}
+
+func (r *RelatedPages) Neighbours(hops int) []Neighbour {
+
+	seen := map[string]bool{}
+	neighbours := []Neighbour{}
+
+	appendNeighbours := func(pages []RelatedPage, hops int, external bool) {
+		for _, p := range pages {
+			n := Neighbour{Title: p.Title, Hops: hops}
+			if external {
+				n.ProjectName = p.ProjectName
+			}
+			if seen[n.String()] {
+				continue
+			}
+			seen[n.String()] = true
+			neighbours = append(neighbours, n)
+		}
+	}
+
+	appendNeighbours(r.Links1hop, 1, false)
+	appendNeighbours(r.ProjectLinks1hop, 1, true)
+	if hops >= 2 {
+		appendNeighbours(r.Links2hop, 2, false)
+	}
+
+	return neighbours
+}
+
+func (r *RelatedPages) Titles(hops int) []string {
+	titles := []string{}
+	for _, n := range r.Neighbours(hops) {
+		titles = append(titles, n.String())
+	}
+	return titles
+}
+
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+type ExternalLink struct {
+	URL string
+	// Line is the index of the line having the link.
+	Line int
 }
 
 func (p *Page) ExtractExternalLinks() []string {
+	linkURLs := []string{}
+	for _, l := range p.ExternalLinks() {
+		linkURLs = append(linkURLs, l.URL)
+	}
+	return linkURLs
+}
+
+func (p *Page) ExternalLinks() []ExternalLink {
 
 	includes := []string{"http://", "https://"}
 	excludes := []string{".png", ".gif", ".jpg", ".jpeg", ".svg"}
@@ -32,9 +170,9 @@ func (p *Page) ExtractExternalLinks() []string {
 		return ""
 	}
 
-	linkURLs := []string{}
+	links := []ExternalLink{}
 
-	for _, line := range p.Lines {
+	for i, line := range p.Lines {
 		if matched := match(line, includes); matched != "" {
 			if match(line, excludes) != "" {
 				continue
@@ -49,9 +187,9 @@ func (p *Page) ExtractExternalLinks() []string {
 			if foundBracket && strings.Index(line, "]") == len(line)-1 {
 				line = line[:len(line)-1]
 			}
-			linkURLs = append(linkURLs, line)
+			links = append(links, ExternalLink{URL: line, Line: i})
 		}
 	}
 
-	return linkURLs
+	return links
 }
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultRetries      = 2
+	DefaultRetryWait    = 1 // time.Second
+	DefaultMaxRetryWait = 30 * time.Second
+	DefaultRetryJitter  = 0.2
+)
+
+// RetryPolicy controls how the client retries a request throttled by scrapbox
+// (429), failed on the server side (5xx) or failed on the network.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one.
+	// Zero or one disables retrying.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry, doubled on every retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait, including the one requested by Retry-After.
+	MaxDelay time.Duration
+	// Jitter randomizes the wait by the fraction, between 0 and 1.
+	Jitter float64
+}
+
+func NewRetryPolicy(retries int, retryWait int) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: retries + 1,
+		BaseDelay:   time.Duration(retryWait) * time.Second,
+		MaxDelay:    DefaultMaxRetryWait,
+		Jitter:      DefaultRetryJitter,
+	}
+}
+
+func (p RetryPolicy) shouldRetry(attempt int, res *http.Response, err error) bool {
+	if attempt+1 >= p.MaxAttempts {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+func (p RetryPolicy) delay(attempt int, res *http.Response) time.Duration {
+
+	d := p.BaseDelay << uint(attempt)
+	if p.Jitter > 0 && d > 0 {
+		d += time.Duration(p.Jitter * (rand.Float64()*2 - 1) * float64(d))
+	}
+
+	if res != nil {
+		if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+			d = retryAfter
+		}
+	}
+
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
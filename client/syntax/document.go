@@ -0,0 +1,308 @@
+package syntax
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prataprc/goparsec"
+)
+
+// Document is the parsed content of the scrapbox page.
+type Document struct {
+	Lines []*Line
+}
+
+// Line is the line of the document. Indent is the count of leading spaces and tabs.
+type Line struct {
+	Indent int
+	Quote  bool
+	Nodes  []Node
+}
+
+// Node is the element of the line, one of the types below.
+type Node interface {
+	node()
+}
+
+// PlainText is the text without notation.
+type PlainText struct {
+	Text string
+}
+
+// InternalLink is [page] or [/project/page]. Project is empty for the page in the same project.
+type InternalLink struct {
+	Project string
+	Page    string
+}
+
+// ExternalLink is [url], [label url], [url label] or the bare url.
+type ExternalLink struct {
+	URL   string
+	Label string
+}
+
+// Image is [[image]], [image url], [url image] or the bare image url. Link is empty if not linked.
+type Image struct {
+	URL  string
+	Link string
+}
+
+// Icon is [page.icon] or [/project/page.icon].
+type Icon struct {
+	Project string
+	Page    string
+}
+
+// Hashtag is #tag or #[tag with spaces].
+type Hashtag struct {
+	Tag string
+}
+
+// Math is [$ formula].
+type Math struct {
+	Formula string
+}
+
+// Decoration is [[text]] or [*/-_ text]. Level is the count of '*'.
+type Decoration struct {
+	Bold      bool
+	Italic    bool
+	Strike    bool
+	Underline bool
+	Level     int
+	Nodes     []Node
+}
+
+// CodeSpan is `code`.
+type CodeSpan struct {
+	Code string
+}
+
+// CodeBlock is code:filename followed by the indented lines. Language is given
+// by code:filename(language), or the extension of the filename.
+type CodeBlock struct {
+	Filename string
+	Language string
+	Content  string
+}
+
+// Table is table:name followed by the indented lines of the tab separated cells.
+type Table struct {
+	Name string
+	Rows [][]string
+}
+
+func (*PlainText) node()    {}
+func (*InternalLink) node() {}
+func (*ExternalLink) node() {}
+func (*Image) node()        {}
+func (*Icon) node()         {}
+func (*Hashtag) node()      {}
+func (*Math) node()         {}
+func (*Decoration) node()   {}
+func (*CodeSpan) node()     {}
+func (*CodeBlock) node()    {}
+func (*Table) node()        {}
+
+var (
+	imageURL     = regexp.MustCompile("^(https://gyazo.com/[^ \t\n]+|https?://[^ \t\n]+(\\.png|\\.gif|\\.jpg|\\.jpeg))$")
+	codeLanguage = regexp.MustCompile("^(.*)\\(([^()]+)\\)$")
+)
+
+func newDocument(root parsec.Queryable) *Document {
+
+	document := &Document{Lines: []*Line{}}
+	if root == nil {
+		return document
+	}
+
+	for _, child := range root.GetChildren() {
+		document.Lines = append(document.Lines, newLine(child))
+	}
+
+	return document
+}
+
+func newLine(q parsec.Queryable) *Line {
+
+	line := &Line{Nodes: []Node{}}
+	if indent := q.GetAttribute("indent"); len(indent) != 0 {
+		line.Indent, _ = strconv.Atoi(indent[0])
+	}
+
+	switch q.GetName() {
+	case "code_block":
+		line.Nodes = append(line.Nodes, newCodeBlock(joinValues(q.GetChildren()), nil))
+		return line
+	case "table_block":
+		line.Nodes = append(line.Nodes, newTable(joinValues(q.GetChildren()), nil))
+		return line
+	case "quoted_text":
+		line.Quote = true
+	}
+
+	for _, child := range q.GetChildren() {
+		node := newNode(child.GetName(), child.GetValue())
+		// concatenate the texts split by the parser
+		if text, ok := node.(*PlainText); ok && len(line.Nodes) != 0 {
+			if prev, ok := line.Nodes[len(line.Nodes)-1].(*PlainText); ok {
+				prev.Text += text.Text
+				continue
+			}
+		}
+		line.Nodes = append(line.Nodes, node)
+	}
+
+	return line
+}
+
+func newNode(name, value string) Node {
+
+	switch name {
+	case "math":
+		return &Math{Formula: strings.TrimSpace(strings.TrimPrefix(unbracket(value), "$"))}
+	case "styled_url", "styled_text":
+		return newDecoration(unbracket(value))
+	case "project_link":
+		project, page := splitProjectPage(unbracket(value))
+		return &InternalLink{Project: project, Page: page}
+	case "image_link1":
+		image, link := splitFirstField(unbracket(value))
+		return &Image{URL: image, Link: link}
+	case "image_link2":
+		link, image := splitFirstField(unbracket(value))
+		return &Image{URL: image, Link: link}
+	case "labeled_link1":
+		label, url := splitLastField(unbracket(value))
+		return &ExternalLink{URL: url, Label: label}
+	case "labeled_link2":
+		url, label := splitFirstField(unbracket(value))
+		return &ExternalLink{URL: url, Label: label}
+	case "external_link":
+		return &ExternalLink{URL: unbracket(value)}
+	case "page_icon", "icon":
+		project, page := splitProjectPage(strings.TrimSpace(strings.TrimSuffix(unbracket(value), ".icon")))
+		return &Icon{Project: project, Page: page}
+	case "internal_link":
+		if page := unbracket(value); len(page) != 0 {
+			return &InternalLink{Page: page}
+		}
+	case "image":
+		return &Image{URL: value}
+	case "url":
+		return &ExternalLink{URL: value}
+	case "bold_image", "bold_text":
+		if text := unbracket(unbracket(value)); len(text) != 0 {
+			return &Decoration{Bold: true, Level: 1, Nodes: []Node{newURLOrText(text)}}
+		}
+	case "snippet":
+		return &CodeSpan{Code: strings.TrimSuffix(strings.TrimPrefix(value, "`"), "`")}
+	case "tag":
+		tag := strings.TrimPrefix(value, "#")
+		if strings.HasPrefix(tag, "[") {
+			tag = unbracket(tag)
+		}
+		return &Hashtag{Tag: tag}
+	}
+
+	return &PlainText{Text: value}
+}
+
+// newDecoration parses the content of [*/-_ text].
+func newDecoration(content string) *Decoration {
+
+	marks, text := splitFirstField(content)
+	decoration := &Decoration{Nodes: []Node{newURLOrText(text)}}
+	for _, mark := range marks {
+		switch mark {
+		case '*':
+			decoration.Bold = true
+			decoration.Level++
+		case '/':
+			decoration.Italic = true
+		case '-':
+			decoration.Strike = true
+		case '_':
+			decoration.Underline = true
+		}
+	}
+
+	return decoration
+}
+
+func newURLOrText(text string) Node {
+	if imageURL.MatchString(text) {
+		return &Image{URL: text}
+	}
+	if strings.HasPrefix(text, "http://") || strings.HasPrefix(text, "https://") {
+		if !strings.ContainsAny(text, " \t") {
+			return &ExternalLink{URL: text}
+		}
+	}
+	return &PlainText{Text: text}
+}
+
+func unbracket(value string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+}
+
+// splitProjectPage splits /project/page into the project and the page.
+// The value not starting with '/' is the page in the same project.
+func splitProjectPage(value string) (string, string) {
+	if !strings.HasPrefix(value, "/") {
+		return "", value
+	}
+	parts := strings.SplitN(value[1:], "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func splitFirstField(value string) (string, string) {
+	i := strings.IndexAny(value, " \t")
+	if i < 0 {
+		return value, ""
+	}
+	return value[:i], strings.TrimLeft(value[i:], " \t")
+}
+
+func splitLastField(value string) (string, string) {
+	i := strings.LastIndexAny(value, " \t")
+	if i < 0 {
+		return "", value
+	}
+	return strings.TrimRight(value[:i], " \t"), value[i+1:]
+}
+
+func joinValues(children []parsec.Queryable) string {
+	values := []string{}
+	for _, child := range children {
+		values = append(values, child.GetValue())
+	}
+	return strings.Join(values, "")
+}
+
+func newCodeBlock(name string, body []string) *CodeBlock {
+
+	block := &CodeBlock{Filename: name, Language: name, Content: strings.Join(body, "\n")}
+	if matched := codeLanguage.FindStringSubmatch(name); matched != nil {
+		block.Filename, block.Language = matched[1], matched[2]
+	} else if ext := path.Ext(name); len(ext) > 1 {
+		block.Language = ext[1:]
+	}
+
+	return block
+}
+
+func newTable(name string, body []string) *Table {
+
+	table := &Table{Name: name, Rows: [][]string{}}
+	for _, row := range body {
+		table.Rows = append(table.Rows, strings.Split(row, "\t"))
+	}
+
+	return table
+}
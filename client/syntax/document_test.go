@@ -0,0 +1,94 @@
+package syntax
+
+import (
+	"testing"
+
+	"github.com/prataprc/goparsec"
+)
+
+func TestNewNode(t *testing.T) {
+	for _, fixture := range []struct {
+		name     string
+		value    string
+		expected Node
+	}{
+		// link
+		{"math", "[$ 1+2 = 3]", &Math{Formula: "1+2 = 3"}},
+		{"styled_url", "[_-/*/-_ https://avatars1.githubusercontent.com/u/1678258#.png]", &Decoration{Bold: true, Italic: true, Strike: true, Underline: true, Level: 1, Nodes: []Node{&Image{URL: "https://avatars1.githubusercontent.com/u/1678258#.png"}}}},
+		{"styled_url", "[** https://github.com/ohtomi/scrapbox]", &Decoration{Bold: true, Level: 2, Nodes: []Node{&ExternalLink{URL: "https://github.com/ohtomi/scrapbox"}}}},
+		{"styled_text", "[_-/*/-_ github.com/ohtomi/scrapbox]", &Decoration{Bold: true, Italic: true, Strike: true, Underline: true, Level: 1, Nodes: []Node{&PlainText{Text: "github.com/ohtomi/scrapbox"}}}},
+		{"styled_text", "[*** ohtomi scrapbox]", &Decoration{Bold: true, Level: 3, Nodes: []Node{&PlainText{Text: "ohtomi scrapbox"}}}},
+		{"project_link", "[/foo/bar/baz]", &InternalLink{Project: "foo", Page: "bar/baz"}},
+		{"project_link", "[/foo]", &InternalLink{Project: "foo"}},
+		{"image_link1", "[https://avatars1.githubusercontent.com/u/1678258#.png https://avatars1.githubusercontent.com/u/1678258]", &Image{URL: "https://avatars1.githubusercontent.com/u/1678258#.png", Link: "https://avatars1.githubusercontent.com/u/1678258"}},
+		{"image_link2", "[https://avatars1.githubusercontent.com/u/1678258 https://avatars1.githubusercontent.com/u/1678258#.png]", &Image{URL: "https://avatars1.githubusercontent.com/u/1678258#.png", Link: "https://avatars1.githubusercontent.com/u/1678258"}},
+		{"labeled_link1", "[ohtomi avatar https://avatars1.githubusercontent.com/u/1678258]", &ExternalLink{URL: "https://avatars1.githubusercontent.com/u/1678258", Label: "ohtomi avatar"}},
+		{"labeled_link2", "[https://avatars1.githubusercontent.com/u/1678258 ohtomi avatar]", &ExternalLink{URL: "https://avatars1.githubusercontent.com/u/1678258", Label: "ohtomi avatar"}},
+		{"external_link", "[https://avatars1.githubusercontent.com/u/1678258]", &ExternalLink{URL: "https://avatars1.githubusercontent.com/u/1678258"}},
+		{"icon", "[ user.icon]", &Icon{Page: "user"}},
+		{"page_icon", "[/foo/bar/baz .icon]", &Icon{Project: "foo", Page: "bar/baz"}},
+		{"internal_link", "[github.com/ohtomi/scrapbox]", &InternalLink{Page: "github.com/ohtomi/scrapbox"}},
+		{"internal_link", "[]", &PlainText{Text: "[]"}},
+		// image
+		{"image", "https://gyazo.com/1678258/avatar", &Image{URL: "https://gyazo.com/1678258/avatar"}},
+		// url
+		{"url", "https://avatars1.githubusercontent.com/u/1678258", &ExternalLink{URL: "https://avatars1.githubusercontent.com/u/1678258"}},
+		// bold
+		{"bold_image", "[[http://avatars1.githubusercontent.com/u/1678258#.png]]", &Decoration{Bold: true, Level: 1, Nodes: []Node{&Image{URL: "http://avatars1.githubusercontent.com/u/1678258#.png"}}}},
+		{"bold_text", "[[github.com/ohtomi/scrapbox]]", &Decoration{Bold: true, Level: 1, Nodes: []Node{&PlainText{Text: "github.com/ohtomi/scrapbox"}}}},
+		// snippet
+		{"snippet", "`github.com\t/ohtomi/\tscrapbox/`", &CodeSpan{Code: "github.com\t/ohtomi/\tscrapbox/"}},
+		// tag
+		{"tag", "#[ github.com\t/ohtomi/\tscrapbox/ ]", &Hashtag{Tag: " github.com\t/ohtomi/\tscrapbox/ "}},
+		{"tag", "#github.com/ohtomi/scrapbox", &Hashtag{Tag: "github.com/ohtomi/scrapbox"}},
+		// text
+		{"text", "x github.com\t/ohtomi/\tscrapbox/ x", &PlainText{Text: "x github.com\t/ohtomi/\tscrapbox/ x"}},
+	} {
+		assertEqualTo(t, newNode(fixture.name, fixture.value), fixture.expected)
+	}
+}
+
+func TestNewDocument(t *testing.T) {
+	root := &parsec.NonTerminal{
+		Name: "root",
+		Children: []parsec.Queryable{
+			&parsec.NonTerminal{
+				Name:       "code_block",
+				Children:   []parsec.Queryable{&parsec.Terminal{Name: "text", Value: "sample.js"}},
+				Attributes: map[string][]string{"indent": {"0"}},
+			},
+			&parsec.NonTerminal{
+				Name:       "table_block",
+				Children:   []parsec.Queryable{&parsec.Terminal{Name: "text", Value: "sample"}},
+				Attributes: map[string][]string{"indent": {"1"}},
+			},
+			&parsec.NonTerminal{
+				Name: "quoted_text",
+				Children: []parsec.Queryable{
+					&parsec.Terminal{Name: "text", Value: "see "},
+					&parsec.Terminal{Name: "text", Value: "["},
+					&parsec.Terminal{Name: "internal_link", Value: "[scrapbox]"},
+				},
+				Attributes: map[string][]string{"indent": {"2"}},
+			},
+			&parsec.NonTerminal{
+				Name:       "simple_text",
+				Children:   []parsec.Queryable{},
+				Attributes: map[string][]string{"indent": {"0"}},
+			},
+		},
+	}
+
+	assertEqualTo(t, newDocument(root), &Document{
+		Lines: []*Line{
+			{Indent: 0, Nodes: []Node{&CodeBlock{Filename: "sample.js", Language: "js"}}},
+			{Indent: 1, Nodes: []Node{&Table{Name: "sample", Rows: [][]string{}}}},
+			{Indent: 2, Quote: true, Nodes: []Node{&PlainText{Text: "see ["}, &InternalLink{Page: "scrapbox"}}},
+			{Indent: 0, Nodes: []Node{}},
+		},
+	})
+}
+
+func TestNewDocument__nil(t *testing.T) {
+	assertEqualTo(t, newDocument(nil), &Document{Lines: []*Line{}})
+}
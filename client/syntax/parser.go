@@ -2,10 +2,18 @@ package syntax
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/prataprc/goparsec"
 )
 
+var blockHeader = regexp.MustCompile("^([ \t]*)(code|table):(.+)$")
+
+func countIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
 type AST struct {
 	ast    *parsec.AST
 	parser parsec.Parser
@@ -41,15 +49,15 @@ func NewAST() AST {
 	// [url image]
 	image_link2 := parsec.Token("\\[https?://[^ \t\n]+[ \t]+(https://gyazo.com/[^ \t\n]+|https?://[^ \t\n]+(\\.png|\\.gif|\\.jpg|\\.jpeg))\\]", "image_link2")
 	// [text url]
-	labeled_link1 := parsec.Token("\\[[^[\n]+[ \t]+https?://[^ \t\n]+\\]", "labeled_link1")
+	labeled_link1 := parsec.Token("\\[[^\\[\\]\n]+[ \t]+https?://[^ \t\n]+\\]", "labeled_link1")
 	// [url text]
-	labeled_link2 := parsec.Token("\\[https?://[^ \t\n]+[ \t]+[^[\n]+\\]", "labeled_link2")
+	labeled_link2 := parsec.Token("\\[https?://[^ \t\n]+[ \t]+[^\\[\\]\n]+\\]", "labeled_link2")
 	// [url]
 	external_link := parsec.Token("\\[https?://[^[ \t\n]+\\]", "external_link")
 	// [/text(/text)*.icon]
-	page_icon := parsec.Token("\\[(/[^\n]+)+\\.icon\\]", "page_icon")
+	page_icon := parsec.Token("\\[(/[^\\[\\]\n]+)+\\.icon\\]", "page_icon")
 	// [text.icon]
-	icon := parsec.Token("\\[[^\n]+\\.icon\\]", "icon")
+	icon := parsec.Token("\\[[^\\[\\]\n]+\\.icon\\]", "icon")
 	// [text+]
 	internal_link := parsec.Token("\\[[^[\n]*?\\]", "internal_link")
 	// image
@@ -63,9 +71,11 @@ func NewAST() AST {
 	// `text+`
 	snippet := parsec.Token("`[^`]*?`", "snippet")
 	// #[text( text)*] | #text
-	tag := parsec.Token("#(\\[[^[\n]+\\]|[^ \t\n]+)", "tag")
-	// text
-	text := parsec.Token("[^\n]+", "text")
+	tag := parsec.Token("#(\\[[^\\[\\]\n]+\\]|[^ \t\n]+)", "tag")
+	// text, stopping before the notations above. '#' following the non-whitespace is not a tag.
+	text := parsec.Token("([^\\[`#h \t\n]#+|[^\\[`#h\n])+", "text")
+	// the character starting no notation, such as '[' not closed or 'h' not starting the url
+	char := parsec.Token("[^\n]#*", "text")
 
 	token := ast.OrdChoice("token", nil,
 		math,
@@ -86,7 +96,8 @@ func NewAST() AST {
 		bold_text,
 		snippet,
 		tag,
-		text)
+		text,
+		char)
 	rest := ast.Kleene("rest", nil, token)
 
 	callback := func(name string, s parsec.Scanner, node parsec.Queryable) parsec.Queryable {
@@ -112,7 +123,7 @@ func NewAST() AST {
 		}
 
 		rest := node.GetChildren()[2]
-		children := rest.GetChildren()
+		children := joinTexts(rest.GetChildren())
 
 		return &parsec.NonTerminal{Name: newName, Children: children, Attributes: attributes}
 	}
@@ -123,7 +134,69 @@ func NewAST() AST {
 	return AST{ast: ast, parser: root}
 }
 
-func Parse(contents []byte, debug bool) parsec.Queryable {
+// joinTexts concatenates the adjacent texts, which are split by the notations not completed.
+func joinTexts(children []parsec.Queryable) []parsec.Queryable {
+
+	joined := []parsec.Queryable{}
+	for _, child := range children {
+		if child.GetName() == "text" && len(joined) != 0 {
+			if prev, ok := joined[len(joined)-1].(*parsec.Terminal); ok && prev.Name == "text" {
+				prev.Value += child.GetValue()
+				continue
+			}
+		}
+		joined = append(joined, child)
+	}
+
+	return joined
+}
+
+// Parse parses the contents of the scrapbox page into the document.
+// The lines indented under code: and table: are grouped into the block without parsing.
+func Parse(contents []byte, debug bool) *Document {
+
+	document := &Document{Lines: []*Line{}}
+	lines := strings.Split(strings.Replace(string(contents), "\r\n", "\n", -1), "\n")
+
+	start := 0
+	for i := 0; i < len(lines); {
+		header := blockHeader.FindStringSubmatch(lines[i])
+		if header == nil {
+			i++
+			continue
+		}
+
+		if start < i {
+			document.Lines = append(document.Lines, parseLines(lines[start:i], debug)...)
+		}
+
+		indent := len(header[1])
+		body := []string{}
+		for i++; i < len(lines) && countIndent(lines[i]) > indent; i++ {
+			body = append(body, lines[i][indent+1:])
+		}
+
+		var block Node
+		if header[2] == "code" {
+			block = newCodeBlock(header[3], body)
+		} else {
+			block = newTable(header[3], body)
+		}
+		document.Lines = append(document.Lines, &Line{Indent: indent, Nodes: []Node{block}})
+		start = i
+	}
+	if start < len(lines) {
+		document.Lines = append(document.Lines, parseLines(lines[start:], debug)...)
+	}
+
+	return document
+}
+
+func parseLines(lines []string, debug bool) []*Line {
+	return newDocument(parse([]byte(strings.Join(lines, "\n")), debug)).Lines
+}
+
+func parse(contents []byte, debug bool) parsec.Queryable {
 	ast := NewAST()
 	scanner := parsec.NewScanner(contents).SetWSPattern("\r\n")
 	queryable, _ := ast.ast.Parsewith(ast.parser, scanner)
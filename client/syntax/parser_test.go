@@ -21,7 +21,7 @@ func TestParse__indent_level(t *testing.T) {
 		{" \t ", 3},
 		{"\t \t", 3},
 	} {
-		queryable := Parse([]byte(fixture.source), enablePrettyPrint)
+		queryable := parse([]byte(fixture.source), enablePrettyPrint)
 
 		if queryable == nil {
 			t.Fatalf("Failed to parse")
@@ -77,7 +77,7 @@ func TestParse__single_node(t *testing.T) {
 		// text
 		{"x github.com\t/ohtomi/\tscrapbox/ x", "text", "x github.com\t/ohtomi/\tscrapbox/ x"},
 	} {
-		queryable := Parse([]byte(fixture.source), enablePrettyPrint)
+		queryable := parse([]byte(fixture.source), enablePrettyPrint)
 
 		if queryable == nil {
 			t.Fatalf("Failed to parse")
@@ -110,7 +110,7 @@ func TestParse__many_nodes(t *testing.T) {
 			[]string{"[$ 1+2 = 3]", "[_-/*/-_ https://avatars1.githubusercontent.com/u/1678258#.png]"},
 		},
 	} {
-		queryable := Parse([]byte(fixture.source), enablePrettyPrint)
+		queryable := parse([]byte(fixture.source), enablePrettyPrint)
 
 		if queryable == nil {
 			t.Fatalf("Failed to parse")
@@ -132,6 +132,54 @@ func TestParse__many_nodes(t *testing.T) {
 	}
 }
 
+func TestParse__inline_nodes(t *testing.T) {
+	for _, fixture := range []struct {
+		original string
+		expected *Line
+	}{
+		{
+			"a [link] b",
+			&Line{Nodes: []Node{&PlainText{Text: "a "}, &InternalLink{Page: "link"}, &PlainText{Text: " b"}}},
+		},
+		{
+			"text [[bold]]",
+			&Line{Nodes: []Node{&PlainText{Text: "text "}, &Decoration{Bold: true, Level: 1, Nodes: []Node{&PlainText{Text: "bold"}}}}},
+		},
+		{
+			"[$ 1+2 = 3] [* x]",
+			&Line{Nodes: []Node{&Math{Formula: "1+2 = 3"}, &PlainText{Text: " "}, &Decoration{Bold: true, Level: 1, Nodes: []Node{&PlainText{Text: "x"}}}}},
+		},
+		{
+			"see #tag and `code` at https://scrapbox.io/help",
+			&Line{Nodes: []Node{&PlainText{Text: "see "}, &Hashtag{Tag: "tag"}, &PlainText{Text: " and "}, &CodeSpan{Code: "code"}, &PlainText{Text: " at "}, &ExternalLink{URL: "https://scrapbox.io/help"}}},
+		},
+		{
+			"C# with [[label https://scrapbox.io]] or [label https://scrapbox.io] [a.icon] [not closed",
+			&Line{Nodes: []Node{
+				&PlainText{Text: "C# with "},
+				&Decoration{Bold: true, Level: 1, Nodes: []Node{&PlainText{Text: "label https://scrapbox.io"}}},
+				&PlainText{Text: " or "},
+				&ExternalLink{URL: "https://scrapbox.io", Label: "label"},
+				&PlainText{Text: " "},
+				&Icon{Page: "a"},
+				&PlainText{Text: " [not closed"},
+			}},
+		},
+		{
+			" item [link] #[tag b]",
+			&Line{Indent: 1, Nodes: []Node{&PlainText{Text: "item "}, &InternalLink{Page: "link"}, &PlainText{Text: " "}, &Hashtag{Tag: "tag b"}}},
+		},
+		{
+			"\t> quoted [/help-jp/link] [/ `a`]",
+			&Line{Indent: 1, Quote: true, Nodes: []Node{&PlainText{Text: " quoted "}, &InternalLink{Project: "help-jp", Page: "link"}, &PlainText{Text: " "}, &Decoration{Italic: true, Nodes: []Node{&PlainText{Text: "`a`"}}}}},
+		},
+	} {
+		document := Parse([]byte(fixture.original), enablePrettyPrint)
+
+		assertEqualTo(t, document.Lines, []*Line{fixture.expected})
+	}
+}
+
 func TestParse__quoted_node(t *testing.T) {
 	for _, fixture := range []struct {
 		original string
@@ -171,7 +219,7 @@ func TestParse__quoted_node(t *testing.T) {
 			},
 		},
 	} {
-		queryable := Parse([]byte(fixture.original), enablePrettyPrint)
+		queryable := parse([]byte(fixture.original), enablePrettyPrint)
 
 		if queryable == nil {
 			t.Fatalf("Failed to parse")
@@ -217,7 +265,7 @@ func TestParse__code_directive_node(t *testing.T) {
 			},
 		},
 	} {
-		queryable := Parse([]byte(fixture.original), enablePrettyPrint)
+		queryable := parse([]byte(fixture.original), enablePrettyPrint)
 
 		if queryable == nil {
 			t.Fatalf("Failed to parse")
@@ -263,7 +311,7 @@ func TestParse__table_directive_node(t *testing.T) {
 			},
 		},
 	} {
-		queryable := Parse([]byte(fixture.original), enablePrettyPrint)
+		queryable := parse([]byte(fixture.original), enablePrettyPrint)
 
 		if queryable == nil {
 			t.Fatalf("Failed to parse")
@@ -284,6 +332,58 @@ func TestParse__table_directive_node(t *testing.T) {
 	}
 }
 
+func TestParse__code_block(t *testing.T) {
+	for _, fixture := range []struct {
+		original string
+		expected []*Line
+	}{
+		{
+			"code:sample.js\n" +
+				" function hello() {\n" +
+				" \treturn 'hello'\n" +
+				" }",
+			[]*Line{
+				{Indent: 0, Nodes: []Node{&CodeBlock{Filename: "sample.js", Language: "js", Content: "function hello() {\n\treturn 'hello'\n}"}}},
+			},
+		},
+		{
+			"\tcode:sample(go)\n" +
+				"\t\tpackage main\n" +
+				"\t code:sample.js\n" +
+				"code:Makefile",
+			[]*Line{
+				{Indent: 1, Nodes: []Node{&CodeBlock{Filename: "sample", Language: "go", Content: "package main\ncode:sample.js"}}},
+				{Indent: 0, Nodes: []Node{&CodeBlock{Filename: "Makefile", Language: "Makefile", Content: ""}}},
+			},
+		},
+	} {
+		document := Parse([]byte(fixture.original), enablePrettyPrint)
+
+		assertEqualTo(t, document.Lines, fixture.expected)
+	}
+}
+
+func TestParse__table_block(t *testing.T) {
+	for _, fixture := range []struct {
+		original string
+		expected []*Line
+	}{
+		{
+			"table:sample\r\n" +
+				" a\tb\tc\r\n" +
+				" 1\t\t3\r\n" +
+				"  table:nested",
+			[]*Line{
+				{Indent: 0, Nodes: []Node{&Table{Name: "sample", Rows: [][]string{{"a", "b", "c"}, {"1", "", "3"}, {" table:nested"}}}}},
+			},
+		},
+	} {
+		document := Parse([]byte(fixture.original), enablePrettyPrint)
+
+		assertEqualTo(t, document.Lines, fixture.expected)
+	}
+}
+
 func assertEqualTo(t *testing.T, actual, expected interface{}) {
 	if !reflect.DeepEqual(actual, expected) {
 		t.Fatalf("Got %+v, but Want %+v", actual, expected)
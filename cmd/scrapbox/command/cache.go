@@ -0,0 +1,625 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/ohtomi/scrapbox/client"
+)
+
+type CacheCommand struct {
+	Meta
+}
+
+func (c *CacheCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *CacheCommand) Synopsis() string {
+	return "Inspect and prune the local cache"
+}
+
+func (c *CacheCommand) Help() string {
+	helpText := `usage: scrapbox cache <sub command> [options...] [arguments...]
+
+Sub Commands:
+  ls       List cached pages and queries with their size and age
+  stat     Summarize cached pages and queries per project
+  purge    Remove cached pages and queries of the host, project or page
+  gc       Remove cached pages and queries by age or total size
+  migrate  Move cached pages and queries left by the previous versions
+`
+	return strings.TrimSpace(helpText)
+}
+
+type cacheFileRecord struct {
+	Kind     string    `json:"kind"`
+	Host     string    `json:"host"`
+	Project  string    `json:"project"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	StoredAt time.Time `json:"storedAt"`
+}
+
+func (r *cacheFileRecord) Header() []string {
+	return []string{"kind", "host", "project", "name", "size", "storedAt"}
+}
+
+func (r *cacheFileRecord) Row() []string {
+	return []string{r.Kind, r.Host, r.Project, r.Name, strconv.FormatInt(r.Size, 10), formatTime(r.StoredAt)}
+}
+
+func (r *cacheFileRecord) String() string {
+	return fmt.Sprintf("%-5s %10d %8s %s/%s/%s", r.Kind, r.Size, formatAge(r.StoredAt), r.Host, r.Project, r.Name)
+}
+
+type cacheStatRecord struct {
+	Host    string    `json:"host"`
+	Project string    `json:"project"`
+	Pages   int       `json:"pages"`
+	Queries int       `json:"queries"`
+	Size    int64     `json:"size"`
+	Oldest  time.Time `json:"oldest"`
+	Newest  time.Time `json:"newest"`
+}
+
+func (r *cacheStatRecord) Header() []string {
+	return []string{"host", "project", "pages", "queries", "size", "oldest", "newest"}
+}
+
+func (r *cacheStatRecord) Row() []string {
+	return []string{r.Host, r.Project, strconv.Itoa(r.Pages), strconv.Itoa(r.Queries), strconv.FormatInt(r.Size, 10), formatTime(r.Oldest), formatTime(r.Newest)}
+}
+
+func (r *cacheStatRecord) String() string {
+	return fmt.Sprintf("%s/%s: %d pages, %d queries, %d bytes, oldest %s ago", r.Host, r.Project, r.Pages, r.Queries, r.Size, formatAge(r.Oldest))
+}
+
+// cacheFilter selects cached files by host, project and page. Empty fields match any.
+type cacheFilter struct {
+	host    string
+	project string
+	page    string
+}
+
+func newCacheFilter(host string, args []string) (*cacheFilter, error) {
+
+	if len(args) > 2 {
+		return nil, fmt.Errorf("too many arguments. arguments: %s", strings.Join(args, " "))
+	}
+
+	filter := &cacheFilter{}
+	if len(host) != 0 {
+		if strings.Contains(host, "://") {
+			parsedURL, err := url.ParseRequestURI(host)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the url. host: %s, cause: %s", host, err)
+			}
+			host = parsedURL.Host
+		}
+		if index := strings.Index(host, ":"); index != -1 {
+			host = host[:index]
+		}
+		filter.host = host
+	}
+	if len(args) > 0 {
+		filter.project = args[0]
+	}
+	if len(args) > 1 {
+		filter.page = args[1]
+	}
+
+	return filter, nil
+}
+
+func (f *cacheFilter) empty() bool {
+	return len(f.host) == 0 && len(f.project) == 0 && len(f.page) == 0
+}
+
+func (f *cacheFilter) match(file client.CachedFile) bool {
+	if len(f.host) != 0 && f.host != file.Host {
+		return false
+	}
+	if len(f.project) != 0 && f.project != file.Project {
+		return false
+	}
+	if len(f.page) != 0 && (file.Kind != client.CacheKindPage || f.page != file.Name) {
+		return false
+	}
+	return true
+}
+
+func listCachedFiles(cache *client.FileCache, filter *cacheFilter) ([]client.CachedFile, error) {
+
+	files, err := cache.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []client.CachedFile
+	for _, f := range files {
+		if filter.match(f) {
+			matched = append(matched, f)
+		}
+	}
+	return matched, nil
+}
+
+func removeCachedFiles(cache *client.FileCache, files []client.CachedFile) (int64, error) {
+
+	var size int64
+	for _, f := range files {
+		if err := cache.Invalidate(f.Key); err != nil {
+			return size, err
+		}
+		size += f.Size
+	}
+	if err := cache.Clean(); err != nil {
+		return size, err
+	}
+	return size, nil
+}
+
+func formatAge(t time.Time) string {
+	return time.Now().Sub(t).Truncate(time.Second).String()
+}
+
+// parseSize parses the number of bytes, optionally suffixed with K, M or G.
+func parseSize(value string) (int64, error) {
+
+	number, unit := value, int64(1)
+	switch {
+	case strings.HasSuffix(value, "K"):
+		unit = 1 << 10
+	case strings.HasSuffix(value, "M"):
+		unit = 1 << 20
+	case strings.HasSuffix(value, "G"):
+		unit = 1 << 30
+	}
+	if unit != 1 {
+		number = value[:len(value)-1]
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("size must be a non-negative number of bytes, optionally suffixed with K, M or G. size: %s", value)
+	}
+	return n * unit, nil
+}
+
+type CacheListCommand struct {
+	Meta
+}
+
+func (c *CacheListCommand) Run(args []string) int {
+
+	var (
+		host string
+	)
+
+	flags := flag.NewFlagSet("cache ls", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Error(c.Help())
+	}
+
+	flags.StringVar(&host, "host", "", "")
+	flags.StringVar(&host, "h", "", "")
+	c.FormatFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return int(ExitCodeParseFlagsError)
+	}
+
+	filter, err := newCacheFilter(host, flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return int(ExitCodeBadArgs)
+	}
+
+	printer, err := c.NewPrinter()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return int(ExitCodeBadArgs)
+	}
+
+	// process
+
+	files, err := listCachedFiles(client.NewFileCache(client.DefaultCacheDir()), filter)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to list the local cache. cause: %s", err))
+		return int(ExitCodeError)
+	}
+
+	for _, f := range files {
+		record := &cacheFileRecord{
+			Kind:     f.Kind,
+			Host:     f.Host,
+			Project:  f.Project,
+			Name:     f.Name,
+			Size:     f.Size,
+			StoredAt: f.StoredAt,
+		}
+		if err := printer.Print(record); err != nil {
+			c.Ui.Error(fmt.Sprintf("failed to print the local cache. cause: %s", err))
+			return int(ExitCodeError)
+		}
+	}
+	if err := printer.Flush(); err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to print the local cache. cause: %s", err))
+		return int(ExitCodeError)
+	}
+
+	return int(ExitCodeOK)
+}
+
+func (c *CacheListCommand) Synopsis() string {
+	return "List cached pages and queries with their size and age"
+}
+
+func (c *CacheListCommand) Help() string {
+	helpText := `usage: scrapbox cache ls [options...] [PROJECT [PAGE]]
+
+Options:
+  --host, -h   Scrapbox Host. By default, all hosts.
+  --format     Output Format, one of text, json, jsonl, tsv and csv. By default, text.
+`
+	return strings.TrimSpace(helpText)
+}
+
+type CacheStatCommand struct {
+	Meta
+}
+
+func (c *CacheStatCommand) Run(args []string) int {
+
+	var (
+		host string
+	)
+
+	flags := flag.NewFlagSet("cache stat", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Error(c.Help())
+	}
+
+	flags.StringVar(&host, "host", "", "")
+	flags.StringVar(&host, "h", "", "")
+	c.FormatFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return int(ExitCodeParseFlagsError)
+	}
+
+	filter, err := newCacheFilter(host, flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return int(ExitCodeBadArgs)
+	}
+
+	printer, err := c.NewPrinter()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return int(ExitCodeBadArgs)
+	}
+
+	// process
+
+	files, err := listCachedFiles(client.NewFileCache(client.DefaultCacheDir()), filter)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to list the local cache. cause: %s", err))
+		return int(ExitCodeError)
+	}
+
+	var records []*cacheStatRecord
+	byProject := map[string]*cacheStatRecord{}
+	for _, f := range files {
+		key := f.Host + "/" + f.Project
+		r, ok := byProject[key]
+		if !ok {
+			r = &cacheStatRecord{Host: f.Host, Project: f.Project, Oldest: f.StoredAt, Newest: f.StoredAt}
+			byProject[key] = r
+			records = append(records, r)
+		}
+		if f.Kind == client.CacheKindPage {
+			r.Pages++
+		} else {
+			r.Queries++
+		}
+		r.Size += f.Size
+		if f.StoredAt.Before(r.Oldest) {
+			r.Oldest = f.StoredAt
+		}
+		if f.StoredAt.After(r.Newest) {
+			r.Newest = f.StoredAt
+		}
+	}
+
+	for _, r := range records {
+		if err := printer.Print(r); err != nil {
+			c.Ui.Error(fmt.Sprintf("failed to print the local cache. cause: %s", err))
+			return int(ExitCodeError)
+		}
+	}
+	if err := printer.Flush(); err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to print the local cache. cause: %s", err))
+		return int(ExitCodeError)
+	}
+
+	return int(ExitCodeOK)
+}
+
+func (c *CacheStatCommand) Synopsis() string {
+	return "Summarize cached pages and queries per project"
+}
+
+func (c *CacheStatCommand) Help() string {
+	helpText := `usage: scrapbox cache stat [options...] [PROJECT]
+
+Options:
+  --host, -h   Scrapbox Host. By default, all hosts.
+  --format     Output Format, one of text, json, jsonl, tsv and csv. By default, text.
+`
+	return strings.TrimSpace(helpText)
+}
+
+type CachePurgeCommand struct {
+	Meta
+}
+
+func (c *CachePurgeCommand) Run(args []string) int {
+
+	var (
+		host string
+		all  bool
+	)
+
+	flags := flag.NewFlagSet("cache purge", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Error(c.Help())
+	}
+
+	flags.StringVar(&host, "host", "", "")
+	flags.StringVar(&host, "h", "", "")
+	flags.BoolVar(&all, "all", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return int(ExitCodeParseFlagsError)
+	}
+
+	filter, err := newCacheFilter(host, flags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return int(ExitCodeBadArgs)
+	}
+
+	if filter.empty() && !all {
+		c.Ui.Error("you must set HOST, PROJECT or --all.")
+		return int(ExitCodeBadArgs)
+	}
+
+	// process
+
+	cache := client.NewFileCache(client.DefaultCacheDir())
+	files, err := listCachedFiles(cache, filter)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to list the local cache. cause: %s", err))
+		return int(ExitCodeError)
+	}
+
+	size, err := removeCachedFiles(cache, files)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to purge the local cache. cause: %s", err))
+		return int(ExitCodeError)
+	}
+
+	c.Ui.Info(fmt.Sprintf("purged %d entries, %d bytes.", len(files), size))
+
+	return int(ExitCodeOK)
+}
+
+func (c *CachePurgeCommand) Synopsis() string {
+	return "Remove cached pages and queries of the host, project or page"
+}
+
+func (c *CachePurgeCommand) Help() string {
+	helpText := `usage: scrapbox cache purge [options...] [PROJECT [PAGE]]
+
+Options:
+  --host, -h   Scrapbox Host. By default, all hosts.
+  --all        Remove all cached pages and queries.
+`
+	return strings.TrimSpace(helpText)
+}
+
+type CacheGCCommand struct {
+	Meta
+}
+
+func (c *CacheGCCommand) Run(args []string) int {
+
+	var (
+		olderThan int
+		maxSize   string
+	)
+
+	flags := flag.NewFlagSet("cache gc", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Error(c.Help())
+	}
+
+	flags.IntVar(&olderThan, "older-than", 0, "")
+	flags.StringVar(&maxSize, "max-size", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return int(ExitCodeParseFlagsError)
+	}
+
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(fmt.Sprintf("too many arguments. arguments: %s", strings.Join(flags.Args(), " ")))
+		return int(ExitCodeBadArgs)
+	}
+
+	if olderThan < 0 {
+		c.Ui.Error(fmt.Sprintf("older-than must not be negative. older-than: %d", olderThan))
+		return int(ExitCodeBadArgs)
+	}
+
+	budget := int64(-1)
+	if len(maxSize) != 0 {
+		size, err := parseSize(maxSize)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return int(ExitCodeBadArgs)
+		}
+		budget = size
+	}
+
+	if olderThan == 0 && budget < 0 {
+		c.Ui.Error("you must set --older-than or --max-size.")
+		return int(ExitCodeBadArgs)
+	}
+
+	// process
+
+	cache := client.NewFileCache(client.DefaultCacheDir())
+	files, err := cache.Files()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to list the local cache. cause: %s", err))
+		return int(ExitCodeError)
+	}
+
+	// newest first, so that the oldest entries are removed beyond the budget
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].StoredAt.After(files[j].StoredAt)
+	})
+
+	var (
+		removed []client.CachedFile
+		total   int64
+		full    bool
+	)
+	threshold := time.Now().Add(-time.Duration(olderThan) * time.Second)
+	for _, f := range files {
+		if olderThan > 0 && f.StoredAt.Before(threshold) {
+			removed = append(removed, f)
+			continue
+		}
+		if full || (budget >= 0 && total+f.Size > budget) {
+			removed = append(removed, f)
+			full = true
+			continue
+		}
+		total += f.Size
+	}
+
+	size, err := removeCachedFiles(cache, removed)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to collect the local cache. cause: %s", err))
+		return int(ExitCodeError)
+	}
+
+	c.Ui.Info(fmt.Sprintf("removed %d entries, %d bytes. %d bytes remain.", len(removed), size, total))
+
+	return int(ExitCodeOK)
+}
+
+func (c *CacheGCCommand) Synopsis() string {
+	return "Remove cached pages and queries by age or total size"
+}
+
+func (c *CacheGCCommand) Help() string {
+	helpText := `usage: scrapbox cache gc [options...]
+
+Options:
+  --older-than Remove Entries Stored before the seconds.
+  --max-size   Remove the Oldest Entries beyond the Total Size, in bytes or with K, M, G suffix.
+`
+	return strings.TrimSpace(helpText)
+}
+
+type CacheMigrateCommand struct {
+	Meta
+}
+
+func (c *CacheMigrateCommand) Run(args []string) int {
+
+	flags := flag.NewFlagSet("cache migrate", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Error(c.Help())
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return int(ExitCodeParseFlagsError)
+	}
+
+	dirs := flags.Args()
+	if len(dirs) == 0 {
+		dirs = client.LegacyHomeDirs
+	}
+
+	dst, err := NewFileCache()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return int(ExitCodeBadArgs)
+	}
+
+	// process
+
+	dstDir, err := filepath.Abs(dst.Dir)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to locate the local cache. cause: %s", err))
+		return int(ExitCodeError)
+	}
+
+	for _, dir := range dirs {
+		srcDir, err := filepath.Abs(client.ExpandHomeDir(dir))
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("failed to locate the local cache. dir: %s, cause: %s", dir, err))
+			return int(ExitCodeError)
+		}
+		if srcDir == dstDir {
+			continue
+		}
+		if fs, err := os.Stat(srcDir); err != nil || !fs.IsDir() {
+			continue
+		}
+
+		src := client.NewFileCache(srcDir)
+		moved, err := client.MoveFileCache(src, dst)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("failed to migrate the local cache. dir: %s, cause: %s", srcDir, err))
+			return int(ExitCodeError)
+		}
+		c.Ui.Info(fmt.Sprintf("moved %d entries from %s to %s.", moved, srcDir, dstDir))
+		c.warnUnmigrated(src)
+	}
+	c.warnUnmigrated(dst)
+
+	return int(ExitCodeOK)
+}
+
+func (c *CacheMigrateCommand) warnUnmigrated(cache *client.FileCache) {
+	for _, name := range cache.Unmigrated() {
+		c.Ui.Warn(fmt.Sprintf("left the file not recognized as the local cache. file: %s", name))
+	}
+}
+
+func (c *CacheMigrateCommand) Synopsis() string {
+	return "Move cached pages and queries left by the previous versions"
+}
+
+func (c *CacheMigrateCommand) Help() string {
+	helpText := `usage: scrapbox cache migrate [DIRs...]
+
+Move cached pages and queries in DIRs into the local cache directory.
+By default, DIRs are ./.scrapbox and ~/.scrapbox used by the previous versions.
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,240 @@
+package command
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ohtomi/scrapbox/client"
+)
+
+func setUpCacheDir(t *testing.T) (*client.FileCache, func()) {
+
+	dir, err := ioutil.TempDir("", "scrapbox-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	revertHome := SetTestEnv(client.EnvHome, dir)
+
+	cache := client.NewFileCache(dir)
+	now := time.Now()
+	for i, key := range []string{
+		"page/scrapbox.io/go-scrapbox/title",
+		"page/scrapbox.io/go-scrapbox/a%2Fb",
+		"query/scrapbox.io/go-scrapbox/english/0-100",
+		"page/example.com/other/title",
+	} {
+		entry := &client.CacheEntry{
+			Body:     []byte(strings.Repeat("x", 100)),
+			StoredAt: now.Add(-time.Duration(i) * time.Hour),
+		}
+		if err := cache.Put(key, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return cache, func() {
+		revertHome()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCacheListCommand__filter_by_project(t *testing.T) {
+
+	_, tearDown := setUpCacheDir(t)
+	defer tearDown()
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &CacheListCommand{
+		Meta: *meta,
+	}
+
+	args := []string{"--host", "https://scrapbox.io", "--format", "tsv", "go-scrapbox"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	lines := strings.Split(strings.TrimSpace(outStream.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Output is %q, but want header and 3 entries", outStream.String())
+	}
+	for _, expected := range []string{"page\tscrapbox.io\tgo-scrapbox\ta/b\t", "query\tscrapbox.io\tgo-scrapbox\tenglish/0-100\t"} {
+		if !strings.Contains(outStream.String(), expected) {
+			t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+		}
+	}
+}
+
+func TestCacheListCommand__ignore_env_host(t *testing.T) {
+
+	_, tearDown := setUpCacheDir(t)
+	defer tearDown()
+
+	reverter := SetTestEnv(EnvScrapboxHost, "https://scrapbox.io")
+	defer reverter()
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &CacheListCommand{
+		Meta: *meta,
+	}
+
+	args := []string{"--format", "tsv"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	lines := strings.Split(strings.TrimSpace(outStream.String()), "\n")
+	if len(lines) != 5 || !strings.Contains(outStream.String(), "page\texample.com\tother\ttitle\t") {
+		t.Fatalf("Output is %q, but want header and 4 entries of all hosts", outStream.String())
+	}
+}
+
+func TestCachePurgeCommand__page(t *testing.T) {
+
+	cache, tearDown := setUpCacheDir(t)
+	defer tearDown()
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &CachePurgeCommand{
+		Meta: *meta,
+	}
+
+	args := []string{"go-scrapbox", "title"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	if _, err := cache.Get("page/scrapbox.io/go-scrapbox/title"); err != client.ErrCacheMiss {
+		t.Fatalf("Got %v, but want purged", err)
+	}
+	if _, err := cache.Get("page/example.com/other/title"); err != nil {
+		t.Fatalf("Got %v, but want kept", err)
+	}
+}
+
+func TestCachePurgeCommand__no_filter(t *testing.T) {
+
+	_, tearDown := setUpCacheDir(t)
+	defer tearDown()
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &CachePurgeCommand{
+		Meta: *meta,
+	}
+
+	args := []string{}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeBadArgs {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeBadArgs)
+	}
+}
+
+func TestCacheGCCommand__max_size(t *testing.T) {
+
+	cache, tearDown := setUpCacheDir(t)
+	defer tearDown()
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &CacheGCCommand{
+		Meta: *meta,
+	}
+
+	args := []string{"--older-than", "9000", "--max-size", "200"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	files, err := cache.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Key != "page/scrapbox.io/go-scrapbox/title" {
+		t.Fatalf("Got %v, but want the newest entry only", files)
+	}
+}
+
+func TestCacheMigrateCommand__legacy_dir(t *testing.T) {
+
+	cache, tearDown := setUpCacheDir(t)
+	defer tearDown()
+
+	legacyDir, err := ioutil.TempDir("", "scrapbox-legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(legacyDir)
+
+	legacyFilePath := filepath.Join(legacyDir, "page", "scrapbox.io", "go-scrapbox", "legacy")
+	os.MkdirAll(filepath.Dir(legacyFilePath), os.ModePerm)
+	if err := ioutil.WriteFile(legacyFilePath, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &CacheMigrateCommand{
+		Meta: *meta,
+	}
+
+	args := []string{legacyDir}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	if _, err := cache.Get(client.PageCacheKey("scrapbox.io", "go-scrapbox", "legacy")); err != nil {
+		t.Fatalf("Got %v, but want migrated", err)
+	}
+	if _, err := os.Stat(legacyDir); !os.IsNotExist(err) {
+		t.Fatalf("%s is left behind", legacyDir)
+	}
+}
@@ -12,4 +12,10 @@ const (
 	ExitCodeProjectNotFound
 	ExitCodePageNotFound
 	ExitCodeFetchFailure
+	ExitCodeUnauthorized
+	ExitCodeRateLimited
+	ExitCodeServerError
+	ExitCodeCanceled
+	ExitCodeTimeout
+	ExitCodeNotCached
 )
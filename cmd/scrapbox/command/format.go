@@ -0,0 +1,152 @@
+package command
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+	FormatTSV   = "tsv"
+	FormatCSV   = "csv"
+)
+
+var Formats = []string{FormatText, FormatJSON, FormatJSONL, FormatTSV, FormatCSV}
+
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatPretty   = "pretty"
+)
+
+// PageFormats render the whole page instead of printing records. They are accepted by read only.
+var PageFormats = []string{FormatMarkdown, FormatHTML, FormatPretty}
+
+func isPageFormat(format string) bool {
+	for _, f := range PageFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Record is a row printed by the commands. It is marshaled as is for json and jsonl.
+type Record interface {
+	// Header returns the column names for tsv and csv.
+	Header() []string
+	// Row returns the column values in the order of Header.
+	Row() []string
+	// String returns the line for text format.
+	String() string
+}
+
+// Printer prints records through cli.Ui in the format.
+type Printer struct {
+	ui     cli.Ui
+	format string
+
+	headerPrinted bool
+	records       []Record
+}
+
+func NewPrinter(ui cli.Ui, format string) (*Printer, error) {
+	for _, f := range Formats {
+		if f == format {
+			return &Printer{ui: ui, format: format}, nil
+		}
+	}
+	return nil, fmt.Errorf("format must be one of %s. format: %s", strings.Join(Formats, ", "), format)
+}
+
+func (p *Printer) Print(r Record) error {
+
+	switch p.format {
+	case FormatJSON:
+		p.records = append(p.records, r)
+	case FormatJSONL:
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		p.ui.Output(string(b))
+	case FormatTSV:
+		if !p.headerPrinted {
+			p.ui.Output(joinTSV(r.Header()))
+			p.headerPrinted = true
+		}
+		p.ui.Output(joinTSV(r.Row()))
+	case FormatCSV:
+		if !p.headerPrinted {
+			if err := p.outputCSV(r.Header()); err != nil {
+				return err
+			}
+			p.headerPrinted = true
+		}
+		return p.outputCSV(r.Row())
+	default:
+		p.ui.Output(r.String())
+	}
+
+	return nil
+}
+
+// Flush prints the records buffered by json format. It must be called after the last Print.
+func (p *Printer) Flush() error {
+
+	if p.format != FormatJSON {
+		return nil
+	}
+
+	records := p.records
+	if records == nil {
+		records = []Record{}
+	}
+	b, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	p.ui.Output(string(b))
+
+	return nil
+}
+
+func (p *Printer) outputCSV(values []string) error {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	if err := w.Write(values); err != nil {
+		return err
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	p.ui.Output(strings.TrimSuffix(buf.String(), "\n"))
+	return nil
+}
+
+var tsvEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+func joinTSV(values []string) string {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = tsvEscaper.Replace(v)
+	}
+	return strings.Join(escaped, "\t")
+}
+
+// formatTime returns the empty string for the zero time, which is omitted in json and jsonl.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
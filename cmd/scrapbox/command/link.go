@@ -4,8 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"net/url"
-	"os"
+	"strconv"
 	"strings"
 
 	"github.com/ohtomi/scrapbox/client"
@@ -16,14 +15,31 @@ type LinkCommand struct {
 	Meta
 }
 
-func (c *LinkCommand) FetchAllLinks(client *client.Client, project, page string) ([]string, error) {
+type linkRecord struct {
+	URL  string `json:"url"`
+	Line int    `json:"line"`
+}
+
+func (r *linkRecord) Header() []string {
+	return []string{"url", "line"}
+}
+
+func (r *linkRecord) Row() []string {
+	return []string{r.URL, strconv.Itoa(r.Line)}
+}
 
-	p, err := client.GetPage(context.Background(), project, page)
+func (r *linkRecord) String() string {
+	return r.URL
+}
+
+func (c *LinkCommand) FetchAllLinks(ctx context.Context, client *client.Client, project, page string) ([]client.ExternalLink, error) {
+
+	p, err := client.GetPage(ctx, project, page)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get page")
 	}
 
-	return p.ExtractExternalLinks(), nil
+	return p.ExternalLinks(), nil
 }
 
 func (c *LinkCommand) Run(args []string) int {
@@ -31,11 +47,6 @@ func (c *LinkCommand) Run(args []string) int {
 	var (
 		project string
 		page    string
-
-		token      string
-		host       string
-		expiration int
-		userAgent  string
 	)
 
 	flags := flag.NewFlagSet("open", flag.ContinueOnError)
@@ -43,12 +54,8 @@ func (c *LinkCommand) Run(args []string) int {
 		c.Ui.Error(c.Help())
 	}
 
-	flags.StringVar(&token, "token", os.Getenv(EnvScrapboxToken), "")
-	flags.StringVar(&token, "t", os.Getenv(EnvScrapboxToken), "")
-	flags.StringVar(&host, "host", os.Getenv(EnvScrapboxHost), "")
-	flags.StringVar(&host, "h", os.Getenv(EnvScrapboxHost), "")
-	flags.IntVar(&expiration, "expire", EnvToInt(EnvExpiration, client.DefaultExpiration), "")
-	flags.StringVar(&userAgent, "ua", os.Getenv(EnvUserAgent), "")
+	c.ClientFlags(flags)
+	c.FormatFlags(flags)
 
 	if err := flags.Parse(args); err != nil {
 		return int(ExitCodeParseFlagsError)
@@ -70,36 +77,37 @@ func (c *LinkCommand) Run(args []string) int {
 		return int(ExitCodePageNotFound)
 	}
 
-	if len(host) == 0 {
-		host = client.DefaultHost
-	}
-
-	parsedURL, err := url.ParseRequestURI(host)
+	printer, err := c.NewPrinter()
 	if err != nil {
-		c.Ui.Error(fmt.Sprintf("failed to parse the url. host: %s, cause: %s", host, err))
-		return int(ExitCodeInvalidURL)
-	}
-
-	if len(userAgent) == 0 {
-		userAgent = client.DefaultUserAgent
+		c.Ui.Error(err.Error())
+		return int(ExitCodeBadArgs)
 	}
 
 	// process
 
-	client, err := client.NewClient(parsedURL, token, expiration, userAgent)
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf("failed to initialize api client. cause: %s", err))
-		return int(ExitCodeError)
+	client, exitCode := c.NewClient()
+	if exitCode != ExitCodeOK {
+		return int(exitCode)
 	}
 
-	linkURLs, err := c.FetchAllLinks(client, project, page)
+	ctx, cancel := NewContext(c.ClientOptions.Timeout)
+	defer cancel()
+
+	links, err := c.FetchAllLinks(ctx, client, project, page)
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("failed to fetch the scrapbox page. cause: %s", err))
-		return int(ExitCodeFetchFailure)
+		return int(ExitCodeOfFetchFailure(err, ExitCodePageNotFound))
 	}
 
-	for _, u := range linkURLs {
-		c.Ui.Output(u)
+	for _, l := range links {
+		if err := printer.Print(&linkRecord{URL: l.URL, Line: l.Line + 1}); err != nil {
+			c.Ui.Error(fmt.Sprintf("failed to print the scrapbox page. cause: %s", err))
+			return int(ExitCodeError)
+		}
+	}
+	if err := printer.Flush(); err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to print the scrapbox page. cause: %s", err))
+		return int(ExitCodeError)
 	}
 
 	return int(ExitCodeOK)
@@ -116,7 +124,16 @@ Options:
   --token, -t  Scrapbox connect.sid used to access private project.
   --host, -h   Scrapbox Host. By default, "https://scrapbox.io".
   --expire     Local Cache Expiration. By default, 3600 seconds.
+  --expire-404 Local Cache Expiration of Missing Pages. By default, 300 seconds.
+  --no-cache   Fetch from Scrapbox regardless of Local Cache.
   --ua         User Agent. By default, "ScrapboxGoClient/x.x.x"
+  --retries    Retry Count on 429, 5xx and Network Failure. By default, 2.
+  --retry-wait Retry Wait, doubled on every retry. By default, 1 second.
+  --rate-limit Requests per Second, optionally with Burst as RATE:BURST. By default, unlimited.
+  --timeout    Timeout in seconds. By default, 0 (no timeout).
+  --offline    Serve Local Cache regardless of Expiration, never accessing Scrapbox.
+  --stale-if-error Serve Local Cache Expired within the seconds on Failure. By default, 0 (never).
+  --format     Output Format, one of text, json, jsonl, tsv and csv. By default, text.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -2,6 +2,8 @@ package command
 
 import (
 	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -181,3 +183,100 @@ func TestLinkCommand__print_no_links(t *testing.T) {
 		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
 	}
 }
+
+func RunLinkAPIServer() *httptest.Server {
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"title":"links","lines":[{"text":"links"},{"text":"see [https://example.com/a]"},{"text":"and https://example.com/b?q=1,2 too"}]}`))
+	}))
+}
+
+func TestLinkCommand__format_jsonl(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &LinkCommand{
+		Meta: *meta,
+	}
+
+	testAPIServer := RunLinkAPIServer()
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "--format", "jsonl", "go-scrapbox", "links"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	expected := `{"url":"https://example.com/a","line":2}` + "\n" + `{"url":"https://example.com/b?q=1,2","line":3}` + "\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
+
+func TestLinkCommand__env_page_format(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &LinkCommand{
+		Meta: *meta,
+	}
+
+	revertFormat := SetTestEnv(EnvFormat, FormatHTML)
+	defer revertFormat()
+
+	testAPIServer := RunLinkAPIServer()
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "go-scrapbox", "links"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	expected := "https://example.com/a\nhttps://example.com/b?q=1,2\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
+
+func TestLinkCommand__format_csv(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &LinkCommand{
+		Meta: *meta,
+	}
+
+	testAPIServer := RunLinkAPIServer()
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "--format", "csv", "go-scrapbox", "links"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	expected := "url,line\nhttps://example.com/a,2\n\"https://example.com/b?q=1,2\",3\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
@@ -4,8 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"net/url"
-	"os"
+	"strconv"
 	"strings"
 
 	"github.com/ohtomi/scrapbox/client"
@@ -16,14 +15,43 @@ type ListCommand struct {
 	Meta
 }
 
-func (c *ListCommand) FetchRelatedPages(client *client.Client, project string, tags []string) ([]string, error) {
+type pageRecord struct {
+	Title   string `json:"title"`
+	Updated string `json:"updated,omitempty"`
+	Views   int    `json:"views"`
+}
 
-	q, err := client.ExecQuery(context.Background(), project, tags, 0, 100)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to execute query")
+func newPageRecord(p client.PageSummary) *pageRecord {
+	return &pageRecord{
+		Title:   p.Title,
+		Updated: formatTime(p.UpdatedAt()),
+		Views:   p.Views,
+	}
+}
+
+func (r *pageRecord) Header() []string {
+	return []string{"title", "updated", "views"}
+}
+
+func (r *pageRecord) Row() []string {
+	return []string{r.Title, r.Updated, strconv.Itoa(r.Views)}
+}
+
+func (r *pageRecord) String() string {
+	return r.Title
+}
+
+func (c *ListCommand) FetchRelatedPages(ctx context.Context, api *client.Client, project string, tags []string, opts client.QueryOptions, fn func(p client.PageSummary)) error {
+
+	it := api.Pages(project, tags, opts)
+	for it.Next(ctx) {
+		fn(it.Page())
+	}
+	if err := it.Err(); err != nil {
+		return errors.Wrap(err, "failed to execute query")
 	}
 
-	return q.Pages, nil
+	return nil
 }
 
 func (c *ListCommand) Run(args []string) int {
@@ -32,10 +60,10 @@ func (c *ListCommand) Run(args []string) int {
 		project string
 		tags    []string
 
-		token      string
-		host       string
-		expiration int
-		userAgent  string
+		sort     string
+		skip     int
+		limit    int
+		maxPages int
 	)
 
 	flags := flag.NewFlagSet("list", flag.ContinueOnError)
@@ -43,12 +71,12 @@ func (c *ListCommand) Run(args []string) int {
 		c.Ui.Error(c.Help())
 	}
 
-	flags.StringVar(&token, "token", os.Getenv(EnvScrapboxToken), "")
-	flags.StringVar(&token, "t", os.Getenv(EnvScrapboxToken), "")
-	flags.StringVar(&host, "host", os.Getenv(EnvScrapboxHost), "")
-	flags.StringVar(&host, "h", os.Getenv(EnvScrapboxHost), "")
-	flags.IntVar(&expiration, "expire", EnvToInt(EnvExpiration, client.DefaultExpiration), "")
-	flags.StringVar(&userAgent, "ua", os.Getenv(EnvUserAgent), "")
+	c.ClientFlags(flags)
+	flags.StringVar(&sort, "sort", client.DefaultSort, "")
+	flags.IntVar(&skip, "skip", 0, "")
+	flags.IntVar(&limit, "limit", client.DefaultLimit, "")
+	flags.IntVar(&maxPages, "max", 0, "")
+	c.FormatFlags(flags)
 
 	if err := flags.Parse(args); err != nil {
 		return int(ExitCodeParseFlagsError)
@@ -66,36 +94,55 @@ func (c *ListCommand) Run(args []string) int {
 		return int(ExitCodeProjectNotFound)
 	}
 
-	if len(host) == 0 {
-		host = client.DefaultHost
-	}
-
-	parsedURL, err := url.ParseRequestURI(host)
+	printer, err := c.NewPrinter()
 	if err != nil {
-		c.Ui.Error(fmt.Sprintf("failed to parse the url. host: %s, cause: %s", host, err))
-		return int(ExitCodeInvalidURL)
+		c.Ui.Error(err.Error())
+		return int(ExitCodeBadArgs)
 	}
 
-	if len(userAgent) == 0 {
-		userAgent = client.DefaultUserAgent
+	if !client.IsValidSort(sort) {
+		c.Ui.Error(fmt.Sprintf("sort must be one of %s. sort: %s", strings.Join(client.Sorts, ", "), sort))
+		return int(ExitCodeBadArgs)
+	}
+	if skip < 0 || limit < 1 || maxPages < 0 {
+		c.Ui.Error(fmt.Sprintf("skip and max must not be negative, limit must be positive. skip: %d, limit: %d, max: %d", skip, limit, maxPages))
+		return int(ExitCodeBadArgs)
 	}
 
 	// process
 
-	client, err := client.NewClient(parsedURL, token, expiration, userAgent)
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf("failed to initialize api client. cause: %s", err))
-		return int(ExitCodeError)
+	api, exitCode := c.NewClient()
+	if exitCode != ExitCodeOK {
+		return int(exitCode)
+	}
+
+	ctx, cancel := NewContext(c.ClientOptions.Timeout)
+	defer cancel()
+
+	opts := client.QueryOptions{
+		Skip:  skip,
+		Limit: limit,
+		Sort:  sort,
+		Max:   maxPages,
 	}
 
-	relatedPages, err := c.FetchRelatedPages(client, project, tags)
+	var printErr error
+	err = c.FetchRelatedPages(ctx, api, project, tags, opts, func(p client.PageSummary) {
+		if printErr == nil {
+			printErr = printer.Print(newPageRecord(p))
+		}
+	})
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("failed to fetch the scrapbox page. cause: %s", err))
-		return int(ExitCodeFetchFailure)
+		return int(ExitCodeOfFetchFailure(err, ExitCodeProjectNotFound))
 	}
 
-	for _, p := range relatedPages {
-		c.Ui.Output(p)
+	if printErr == nil {
+		printErr = printer.Flush()
+	}
+	if printErr != nil {
+		c.Ui.Error(fmt.Sprintf("failed to print the scrapbox page. cause: %s", printErr))
+		return int(ExitCodeError)
 	}
 
 	return int(ExitCodeOK)
@@ -112,7 +159,20 @@ Options:
   --token, -t  Scrapbox connect.sid used to access private project.
   --host, -h   Scrapbox Host. By default, "https://scrapbox.io".
   --expire     Local Cache Expiration. By default, 3600 seconds.
+  --expire-404 Local Cache Expiration of Missing Pages. By default, 300 seconds.
+  --no-cache   Fetch from Scrapbox regardless of Local Cache.
   --ua         User Agent. By default, "ScrapboxGoClient/x.x.x"
+  --retries    Retry Count on 429, 5xx and Network Failure. By default, 2.
+  --retry-wait Retry Wait, doubled on every retry. By default, 1 second.
+  --rate-limit Requests per Second, optionally with Burst as RATE:BURST. By default, unlimited.
+  --timeout    Timeout in seconds. By default, 0 (no timeout).
+  --offline    Serve Local Cache regardless of Expiration, never accessing Scrapbox.
+  --stale-if-error Serve Local Cache Expired within the seconds on Failure. By default, 0 (never).
+  --sort       Sort Order, one of updated, created, accessed, linked, views and title. By default, updated.
+  --skip       Number of Pages to Skip. By default, 0.
+  --limit      Number of Pages per Request. By default, 100.
+  --max        Number of Pages to Print. By default, 0 (all pages).
+  --format     Output Format, one of text, json, jsonl, tsv and csv. By default, text.
 `
 	return strings.TrimSpace(helpText)
 }
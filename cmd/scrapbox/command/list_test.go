@@ -2,14 +2,15 @@ package command
 
 import (
 	"bytes"
-	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
-	"path"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/mitchellh/cli"
@@ -36,6 +37,19 @@ func NewTestMeta(outStream, errStream io.Writer, inStream io.Reader) *Meta {
 		}}
 }
 
+// fixtures are the responses cached by `make prep` under testdata.
+var fixtures = client.NewFileCache("../../../testdata")
+
+func serveFixture(w http.ResponseWriter, r *http.Request, key string) {
+	entry, err := fixtures.Get(key)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(entry.Body)
+}
+
 func RunAPIServer() *httptest.Server {
 
 	muxAPI := http.NewServeMux()
@@ -43,34 +57,28 @@ func RunAPIServer() *httptest.Server {
 
 	muxAPI.HandleFunc("/api/pages/go-scrapbox/search/query", func(w http.ResponseWriter, r *http.Request) {
 		query := r.URL.Query()
-		skip := query.Get("skip")
-		limit := query.Get("limit")
+		skip, _ := strconv.Atoi(query.Get("skip"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
 		tags := strings.Split(query.Get("q"), " ")
 
-		filename := fmt.Sprintf("%s-%s", skip, limit)
-		directory := path.Join("../../../testdata/query/scrapbox.io/go-scrapbox", path.Join(tags...))
-		filepath := path.Join(directory, client.EncodeFilename(filename))
-		http.ServeFile(w, r, filepath)
+		key := client.QueryCacheKey("scrapbox.io", "go-scrapbox", tags, skip, limit, client.DefaultSort)
+		serveFixture(w, r, key)
 	})
 
 	muxAPI.HandleFunc("/api/pages/go-scrapbox", func(w http.ResponseWriter, r *http.Request) {
 		query := r.URL.Query()
-		skip := query.Get("skip")
-		limit := query.Get("limit")
+		skip, _ := strconv.Atoi(query.Get("skip"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
 
-		filename := fmt.Sprintf("%s-%s", skip, limit)
-		directory := path.Join("../../../testdata/query/scrapbox.io/go-scrapbox")
-		filepath := path.Join(directory, client.EncodeFilename(filename))
-		http.ServeFile(w, r, filepath)
+		key := client.QueryCacheKey("scrapbox.io", "go-scrapbox", nil, skip, limit, client.DefaultSort)
+		serveFixture(w, r, key)
 	})
 
 	muxAPI.HandleFunc("/api/pages/go-scrapbox/", func(w http.ResponseWriter, r *http.Request) {
-		urlPath := r.URL.Path
+		page := strings.TrimPrefix(r.URL.Path, "/api/pages/go-scrapbox/")
 
-		filename := strings.Replace(urlPath, "/api/pages/go-scrapbox/", "", -1)
-		directory := "../../../testdata/page/scrapbox.io/go-scrapbox"
-		filepath := path.Join(directory, client.EncodeFilename(filename))
-		http.ServeFile(w, r, filepath)
+		key := client.PageCacheKey("scrapbox.io", "go-scrapbox", page)
+		serveFixture(w, r, key)
 	})
 
 	return testAPIServer
@@ -209,3 +217,155 @@ func TestListCommand__find_by_project_and_non_tag_keyword(t *testing.T) {
 		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
 	}
 }
+
+func TestListCommand__sort_and_max(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ListCommand{
+		Meta: *meta,
+	}
+
+	var query url.Values
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`{"count":5,"pages":[{"title":"a"},{"title":"b"},{"title":"c"},{"title":"d"},{"title":"e"}]}`))
+	}))
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "--sort", "views", "--skip", "3", "--max", "2", "go-scrapbox"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	if query.Get("sort") != "views" || query.Get("skip") != "3" || query.Get("limit") != "2" {
+		t.Fatalf("Query is %v, but want sort=views, skip=3 and limit=2", query)
+	}
+
+	expected := "a\nb\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
+
+func TestListCommand__bad_sort(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ListCommand{
+		Meta: *meta,
+	}
+
+	args := []string{"--sort", "popularity", "go-scrapbox"}
+	exitStatus := command.Run(args)
+
+	if ExitCode(exitStatus) != ExitCodeBadArgs {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeBadArgs)
+	}
+}
+
+func TestListCommand__env_page_format(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ListCommand{
+		Meta: *meta,
+	}
+
+	revertFormat := SetTestEnv(EnvFormat, FormatMarkdown)
+	defer revertFormat()
+
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"count":2,"pages":[{"title":"a","views":3},{"title":"c","views":0}]}`))
+	}))
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "go-scrapbox"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	expected := "a\nc\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
+
+func TestListCommand__format_tsv(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ListCommand{
+		Meta: *meta,
+	}
+
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"count":2,"pages":[{"title":"a\tb","updated":1500000000,"views":3},{"title":"c","views":0}]}`))
+	}))
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "--format", "tsv", "go-scrapbox"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	expected := "title\tupdated\tviews\n" +
+		"a\\tb\t" + time.Unix(1500000000, 0).Format(time.RFC3339) + "\t3\n" +
+		"c\t\t0\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
+
+func TestListCommand__format_jsonl(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ListCommand{
+		Meta: *meta,
+	}
+
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"count":2,"pages":[{"title":"a\tb","updated":1500000000,"views":3},{"title":"c","views":0}]}`))
+	}))
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "--format", "jsonl", "go-scrapbox"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	expected := `{"title":"a\tb","updated":"` + time.Unix(1500000000, 0).Format(time.RFC3339) + `","views":3}` + "\n" +
+		`{"title":"c","views":0}` + "\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
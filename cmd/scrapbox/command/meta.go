@@ -1,19 +1,42 @@
 package command
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/mattn/go-isatty"
 	"github.com/mitchellh/cli"
+	"github.com/ohtomi/scrapbox/client"
+	"github.com/pkg/errors"
 )
 
 const (
-	EnvScrapboxToken = "SCRAPBOX_TOKEN"
-	EnvScrapboxHost  = "SCRAPBOX_HOST"
-	EnvExpiration    = "SCRAPBOX_EXPIRATION"
-	EnvUserAgent     = "SCRAPBOX_USER_AGENT"
+	EnvScrapboxToken      = "SCRAPBOX_TOKEN"
+	EnvScrapboxHost       = "SCRAPBOX_HOST"
+	EnvExpiration         = "SCRAPBOX_EXPIRATION"
+	EnvNegativeExpiration = "SCRAPBOX_EXPIRATION_404"
+	EnvUserAgent          = "SCRAPBOX_USER_AGENT"
+	EnvRetries            = "SCRAPBOX_RETRIES"
+	EnvRetryWait          = "SCRAPBOX_RETRY_WAIT"
+	EnvRateLimit          = "SCRAPBOX_RATE_LIMIT"
+	EnvTimeout            = "SCRAPBOX_TIMEOUT"
+	EnvFormat             = "SCRAPBOX_FORMAT"
+	EnvOffline            = "SCRAPBOX_OFFLINE"
+	EnvStaleIfError       = "SCRAPBOX_STALE_IF_ERROR"
+	EnvCompression        = "SCRAPBOX_COMPRESSION"
+	EnvTemplate           = "SCRAPBOX_TEMPLATE"
 )
 
+// EnvNoColor disables colored output if set, see https://no-color.org/
+const EnvNoColor = "NO_COLOR"
+
 const (
 	EnvDebug       = "SCRAPBOX_DEBUG"
 	EnvLongRunTest = "SCRAPBOX_LONG_RUN_TEST"
@@ -32,7 +55,212 @@ func EnvToInt(name string, value int) int {
 	return parsedInt
 }
 
+func EnvToBool(name string, value bool) bool {
+	parsedBool, err := strconv.ParseBool(os.Getenv(name))
+	if err != nil {
+		return value
+	}
+	return parsedBool
+}
+
+// ColorEnabled reports whether stdout is the terminal and NO_COLOR is not set.
+func ColorEnabled() bool {
+	if len(os.Getenv(EnvNoColor)) != 0 {
+		return false
+	}
+	return isatty.IsTerminal(os.Stdout.Fd())
+}
+
+// NewFileCache returns the local cache compressed as SCRAPBOX_COMPRESSION.
+func NewFileCache() (*client.FileCache, error) {
+
+	cache := client.NewFileCache(client.DefaultCacheDir())
+	if compression := os.Getenv(EnvCompression); len(compression) != 0 {
+		if !client.IsValidCompression(compression) {
+			return nil, fmt.Errorf("%s must be one of %s. compression: %s", EnvCompression, strings.Join(client.Compressions, ", "), compression)
+		}
+		cache.Compression = compression
+	}
+
+	return cache, nil
+}
+
+// NewContext returns the context canceled by SIGINT or after timeout seconds.
+// Zero timeout means no deadline.
+func NewContext(timeout int) (context.Context, context.CancelFunc) {
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	go func() {
+		select {
+		case <-interrupted:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(interrupted)
+		cancel()
+	}
+}
+
 // Meta contain the meta-option that nearly all subcommand inherited.
 type Meta struct {
 	Ui cli.Ui
+
+	Format        string
+	ClientOptions ClientOptions
+}
+
+// ClientOptions are given by the options of the subcommands accessing Scrapbox.
+type ClientOptions struct {
+	Token        string
+	Host         string
+	Expiration   int
+	Expire404    int
+	NoCache      bool
+	UserAgent    string
+	Retries      int
+	RetryWait    int
+	RateLimit    string
+	Timeout      int
+	Offline      bool
+	StaleIfError int
+}
+
+// ClientFlags defines the options shared by the subcommands accessing Scrapbox.
+func (m *Meta) ClientFlags(flags *flag.FlagSet) {
+	o := &m.ClientOptions
+	flags.StringVar(&o.Token, "token", os.Getenv(EnvScrapboxToken), "")
+	flags.StringVar(&o.Token, "t", os.Getenv(EnvScrapboxToken), "")
+	flags.StringVar(&o.Host, "host", os.Getenv(EnvScrapboxHost), "")
+	flags.StringVar(&o.Host, "h", os.Getenv(EnvScrapboxHost), "")
+	flags.IntVar(&o.Expiration, "expire", EnvToInt(EnvExpiration, client.DefaultExpiration), "")
+	flags.IntVar(&o.Expire404, "expire-404", EnvToInt(EnvNegativeExpiration, client.DefaultNegativeExpiration), "")
+	flags.BoolVar(&o.NoCache, "no-cache", false, "")
+	flags.StringVar(&o.UserAgent, "ua", os.Getenv(EnvUserAgent), "")
+	flags.IntVar(&o.Retries, "retries", EnvToInt(EnvRetries, client.DefaultRetries), "")
+	flags.IntVar(&o.RetryWait, "retry-wait", EnvToInt(EnvRetryWait, client.DefaultRetryWait), "")
+	flags.StringVar(&o.RateLimit, "rate-limit", os.Getenv(EnvRateLimit), "")
+	flags.IntVar(&o.Timeout, "timeout", EnvToInt(EnvTimeout, 0), "")
+	flags.BoolVar(&o.Offline, "offline", EnvToBool(EnvOffline, false), "")
+	flags.IntVar(&o.StaleIfError, "stale-if-error", EnvToInt(EnvStaleIfError, 0), "")
+}
+
+// NewClient returns the api client configured by the options defined by ClientFlags.
+// On failure, it reports the error through Ui and returns the exit code other than ExitCodeOK.
+func (m *Meta) NewClient() (*client.Client, ExitCode) {
+
+	o := m.ClientOptions
+
+	host := o.Host
+	if len(host) == 0 {
+		host = client.DefaultHost
+	}
+
+	parsedURL, err := url.ParseRequestURI(host)
+	if err != nil {
+		m.Ui.Error(fmt.Sprintf("failed to parse the url. host: %s, cause: %s", host, err))
+		return nil, ExitCodeInvalidURL
+	}
+
+	userAgent := o.UserAgent
+	if len(userAgent) == 0 {
+		userAgent = client.DefaultUserAgent
+	}
+
+	rateLimiter, err := client.ParseRateLimit(o.RateLimit)
+	if err != nil {
+		m.Ui.Error(fmt.Sprintf("failed to parse the rate limit. cause: %s", err))
+		return nil, ExitCodeBadArgs
+	}
+
+	cache, err := NewFileCache()
+	if err != nil {
+		m.Ui.Error(err.Error())
+		return nil, ExitCodeBadArgs
+	}
+
+	expiration, expire404 := o.Expiration, o.Expire404
+	if o.NoCache {
+		expiration, expire404 = 0, 0
+	}
+
+	api, err := client.NewClient(parsedURL, o.Token, expiration, userAgent)
+	if err != nil {
+		m.Ui.Error(fmt.Sprintf("failed to initialize api client. cause: %s", err))
+		return nil, ExitCodeError
+	}
+	api.Retry = client.NewRetryPolicy(o.Retries, o.RetryWait)
+	api.RateLimiter = rateLimiter
+	api.Cache = cache
+	api.NegativeExpiration = time.Duration(expire404) * time.Second
+	api.Offline = o.Offline
+	api.CachePolicy.StaleIfError = time.Duration(o.StaleIfError) * time.Second
+	api.Warn = m.Ui.Warn
+
+	return api, ExitCodeOK
+}
+
+// FormatFlags defines --format option shared by the subcommands printing records.
+// SCRAPBOX_FORMAT of the page formats falls back to text, since they are accepted by read only.
+func (m *Meta) FormatFlags(flags *flag.FlagSet) {
+	format := os.Getenv(EnvFormat)
+	if isPageFormat(format) {
+		format = ""
+	}
+	flags.StringVar(&m.Format, "format", format, "")
+}
+
+// PageFormatFlags defines --format option of read, accepting the page formats too.
+func (m *Meta) PageFormatFlags(flags *flag.FlagSet) {
+	flags.StringVar(&m.Format, "format", os.Getenv(EnvFormat), "")
+}
+
+// NewPrinter returns the printer of the format given by --format option.
+func (m *Meta) NewPrinter() (*Printer, error) {
+	format := m.Format
+	if len(format) == 0 {
+		format = FormatText
+	}
+	return NewPrinter(m.Ui, format)
+}
+
+// ExitCodeOfFetchFailure maps an error of the api client onto the exit code.
+// notFound is used when the api answers 404 for other than the project.
+func ExitCodeOfFetchFailure(err error, notFound ExitCode) ExitCode {
+	cause := errors.Cause(err)
+	if cause == context.Canceled {
+		return ExitCodeCanceled
+	}
+	if cause == context.DeadlineExceeded {
+		return ExitCodeTimeout
+	}
+
+	switch e := cause.(type) {
+	case *client.NotCachedError:
+		return ExitCodeNotCached
+	case *client.NotFoundError:
+		if e.ProjectNotFound() {
+			return ExitCodeProjectNotFound
+		}
+		return notFound
+	case *client.UnauthorizedError:
+		return ExitCodeUnauthorized
+	case *client.RateLimitedError:
+		return ExitCodeRateLimited
+	case *client.ServerError:
+		return ExitCodeServerError
+	default:
+		return ExitCodeFetchFailure
+	}
 }
@@ -14,6 +14,22 @@ type OpenCommand struct {
 	Meta
 }
 
+type urlRecord struct {
+	URL string `json:"url"`
+}
+
+func (r *urlRecord) Header() []string {
+	return []string{"url"}
+}
+
+func (r *urlRecord) Row() []string {
+	return []string{r.URL}
+}
+
+func (r *urlRecord) String() string {
+	return r.URL
+}
+
 func (c *OpenCommand) BuildPageURL(host, project, page string) string {
 	return client.GetURL(host, project, page)
 }
@@ -34,6 +50,7 @@ func (c *OpenCommand) Run(args []string) int {
 
 	flags.StringVar(&host, "host", os.Getenv(EnvScrapboxHost), "")
 	flags.StringVar(&host, "h", os.Getenv(EnvScrapboxHost), "")
+	c.FormatFlags(flags)
 
 	if err := flags.Parse(args); err != nil {
 		return int(ExitCodeParseFlagsError)
@@ -55,11 +72,17 @@ func (c *OpenCommand) Run(args []string) int {
 		return int(ExitCodePageNotFound)
 	}
 
+	printer, err := c.NewPrinter()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return int(ExitCodeBadArgs)
+	}
+
 	if len(host) == 0 {
 		host = client.DefaultHost
 	}
 
-	_, err := url.ParseRequestURI(host)
+	_, err = url.ParseRequestURI(host)
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("failed to parse the url. host: %s, cause: %s", host, err))
 		return int(ExitCodeInvalidURL)
@@ -68,7 +91,14 @@ func (c *OpenCommand) Run(args []string) int {
 	// process
 
 	pageURL := c.BuildPageURL(host, project, page)
-	c.Ui.Output(pageURL)
+	if err := printer.Print(&urlRecord{URL: pageURL}); err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to print the url. cause: %s", err))
+		return int(ExitCodeError)
+	}
+	if err := printer.Flush(); err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to print the url. cause: %s", err))
+		return int(ExitCodeError)
+	}
 
 	return int(ExitCodeOK)
 }
@@ -82,6 +112,7 @@ func (c *OpenCommand) Help() string {
 
 Options:
   --host, -h   Scrapbox Host. By default, "https://scrapbox.io".
+  --format     Output Format, one of text, json, jsonl, tsv and csv. By default, text.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -163,3 +163,45 @@ func TestOpenCommand__print_url_having_japanese(t *testing.T) {
 		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
 	}
 }
+
+func TestOpenCommand__format_json(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &OpenCommand{
+		Meta: *meta,
+	}
+
+	args := []string{"--format", "json", "go-scrapbox", "title having whitespaces"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	expected := "[\n  {\n    \"url\": \"https://scrapbox.io/go-scrapbox/title%20having%20whitespaces\"\n  }\n]\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
+
+func TestOpenCommand__bad_format(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &OpenCommand{
+		Meta: *meta,
+	}
+
+	args := []string{"--format", "yaml", "go-scrapbox", "title having whitespaces"}
+	exitStatus := command.Run(args)
+
+	if ExitCode(exitStatus) != ExitCodeBadArgs {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeBadArgs)
+	}
+}
@@ -4,26 +4,105 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"net/url"
+	"html/template"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/ohtomi/scrapbox/client"
+	"github.com/ohtomi/scrapbox/client/render"
 	"github.com/pkg/errors"
 )
 
+// DefaultTemplateFile is the html template looked up in the config directory.
+const DefaultTemplateFile = "template.html"
+
 type ReadCommand struct {
 	Meta
 }
 
-func (c *ReadCommand) FetchContent(client *client.Client, project, page string) ([]string, error) {
+type lineRecord struct {
+	Line    int    `json:"line"`
+	ID      string `json:"id"`
+	Text    string `json:"text"`
+	UserID  string `json:"userId"`
+	Updated string `json:"updated,omitempty"`
+}
+
+func newLineRecord(i int, l client.Line) *lineRecord {
+	return &lineRecord{
+		Line:    i + 1,
+		ID:      l.ID,
+		Text:    l.Text,
+		UserID:  l.UserID,
+		Updated: formatTime(l.Updated),
+	}
+}
+
+func (r *lineRecord) Header() []string {
+	return []string{"line", "id", "text", "userId", "updated"}
+}
+
+func (r *lineRecord) Row() []string {
+	return []string{strconv.Itoa(r.Line), r.ID, r.Text, r.UserID, r.Updated}
+}
 
-	p, err := client.GetPage(context.Background(), project, page)
+func (r *lineRecord) String() string {
+	return r.Text
+}
+
+func (c *ReadCommand) FetchContent(ctx context.Context, client *client.Client, project, page string) ([]client.Line, error) {
+
+	p, err := client.GetPage(ctx, project, page)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get page")
 	}
 
-	return p.Lines, nil
+	return p.LineDetails, nil
+}
+
+// LoadTemplate parses the html template file. Empty filename means template.html
+// in the config directory, and nil is returned if it does not exist.
+func (c *ReadCommand) LoadTemplate(filename string) (*template.Template, error) {
+
+	if len(filename) == 0 {
+		filename = filepath.Join(client.DefaultConfigDir(), DefaultTemplateFile)
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+
+	tmpl, err := template.ParseFiles(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the template. filename: %s", filename)
+	}
+
+	return tmpl, nil
+}
+
+func (c *ReadCommand) RenderContent(format, host, project string, lines []client.Line, tmpl *template.Template, colored bool) (string, error) {
+
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.Text
+	}
+	page := render.NewPage(host, project, texts)
+
+	switch format {
+	case FormatMarkdown:
+		return strings.TrimSuffix(render.Markdown(page), "\n"), nil
+	case FormatHTML:
+		content, err := render.HTML(page, tmpl)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to execute the template")
+		}
+		return strings.TrimSuffix(content, "\n"), nil
+	case FormatPretty:
+		return strings.TrimSuffix(render.Terminal(page, colored), "\n"), nil
+	default:
+		return "", fmt.Errorf("format must be one of %s. format: %s", strings.Join(PageFormats, ", "), format)
+	}
 }
 
 func (c *ReadCommand) Run(args []string) int {
@@ -32,10 +111,7 @@ func (c *ReadCommand) Run(args []string) int {
 		project string
 		page    string
 
-		token      string
-		host       string
-		expiration int
-		userAgent  string
+		tmplFile string
 	)
 
 	flags := flag.NewFlagSet("read", flag.ContinueOnError)
@@ -43,12 +119,9 @@ func (c *ReadCommand) Run(args []string) int {
 		c.Ui.Error(c.Help())
 	}
 
-	flags.StringVar(&token, "token", os.Getenv(EnvScrapboxToken), "")
-	flags.StringVar(&token, "t", os.Getenv(EnvScrapboxToken), "")
-	flags.StringVar(&host, "host", os.Getenv(EnvScrapboxHost), "")
-	flags.StringVar(&host, "h", os.Getenv(EnvScrapboxHost), "")
-	flags.IntVar(&expiration, "expire", EnvToInt(EnvExpiration, client.DefaultExpiration), "")
-	flags.StringVar(&userAgent, "ua", os.Getenv(EnvUserAgent), "")
+	c.ClientFlags(flags)
+	flags.StringVar(&tmplFile, "template", os.Getenv(EnvTemplate), "")
+	c.PageFormatFlags(flags)
 
 	if err := flags.Parse(args); err != nil {
 		return int(ExitCodeParseFlagsError)
@@ -70,36 +143,66 @@ func (c *ReadCommand) Run(args []string) int {
 		return int(ExitCodePageNotFound)
 	}
 
-	if len(host) == 0 {
-		host = client.DefaultHost
+	if len(c.Format) == 0 {
+		c.Format = FormatPretty
 	}
-
-	parsedURL, err := url.ParseRequestURI(host)
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf("failed to parse the url. host: %s, cause: %s", host, err))
-		return int(ExitCodeInvalidURL)
+	colored := ColorEnabled()
+
+	var printer *Printer
+	if !isPageFormat(c.Format) {
+		p, err := c.NewPrinter()
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return int(ExitCodeBadArgs)
+		}
+		printer = p
 	}
 
-	if len(userAgent) == 0 {
-		userAgent = client.DefaultUserAgent
+	var tmpl *template.Template
+	if c.Format == FormatHTML {
+		t, err := c.LoadTemplate(tmplFile)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return int(ExitCodeBadArgs)
+		}
+		tmpl = t
 	}
 
 	// process
 
-	client, err := client.NewClient(parsedURL, token, expiration, userAgent)
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf("failed to initialize api client. cause: %s", err))
-		return int(ExitCodeError)
+	client, exitCode := c.NewClient()
+	if exitCode != ExitCodeOK {
+		return int(exitCode)
 	}
 
-	lines, err := c.FetchContent(client, project, page)
+	ctx, cancel := NewContext(c.ClientOptions.Timeout)
+	defer cancel()
+
+	lines, err := c.FetchContent(ctx, client, project, page)
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("failed to fetch the scrapbox page. cause: %s", err))
-		return int(ExitCodeFetchFailure)
+		return int(ExitCodeOfFetchFailure(err, ExitCodePageNotFound))
 	}
 
-	for _, l := range lines {
-		c.Ui.Output(l)
+	if printer == nil {
+		content, err := c.RenderContent(c.Format, client.URL.String(), project, lines, tmpl, colored)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("failed to render the scrapbox page. cause: %s", err))
+			return int(ExitCodeError)
+		}
+		c.Ui.Output(content)
+		return int(ExitCodeOK)
+	}
+
+	for i, l := range lines {
+		if err := printer.Print(newLineRecord(i, l)); err != nil {
+			c.Ui.Error(fmt.Sprintf("failed to print the scrapbox page. cause: %s", err))
+			return int(ExitCodeError)
+		}
+	}
+	if err := printer.Flush(); err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to print the scrapbox page. cause: %s", err))
+		return int(ExitCodeError)
 	}
 
 	return int(ExitCodeOK)
@@ -116,7 +219,17 @@ Options:
   --token, -t  Scrapbox connect.sid used to access private project.
   --host, -h   Scrapbox Host. By default, "https://scrapbox.io".
   --expire     Local Cache Expiration. By default, 3600 seconds.
+  --expire-404 Local Cache Expiration of Missing Pages. By default, 300 seconds.
+  --no-cache   Fetch from Scrapbox regardless of Local Cache.
   --ua         User Agent. By default, "ScrapboxGoClient/x.x.x"
+  --retries    Retry Count on 429, 5xx and Network Failure. By default, 2.
+  --retry-wait Retry Wait, doubled on every retry. By default, 1 second.
+  --rate-limit Requests per Second, optionally with Burst as RATE:BURST. By default, unlimited.
+  --timeout    Timeout in seconds. By default, 0 (no timeout).
+  --offline    Serve Local Cache regardless of Expiration, never accessing Scrapbox.
+  --stale-if-error Serve Local Cache Expired within the seconds on Failure. By default, 0 (never).
+  --template   HTML Template of --format html. By default, template.html in the config directory if exists.
+  --format     Output Format, one of text, json, jsonl, tsv, csv, markdown, html and pretty. By default, pretty.
 `
 	return strings.TrimSpace(helpText)
 }
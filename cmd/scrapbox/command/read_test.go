@@ -2,8 +2,14 @@ package command
 
 import (
 	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/mitchellh/cli"
 )
@@ -181,3 +187,421 @@ func TestReadCommand__print_url_having_japanese(t *testing.T) {
 		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
 	}
 }
+
+func TestReadCommand__malformed_response(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ReadCommand{
+		Meta: *meta,
+	}
+
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"NotFoundError","message":"Page not found."}`))
+	}))
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "go-scrapbox", "malformed response"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeFetchFailure {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeFetchFailure)
+	}
+
+	expected := "malformed page response"
+	if !strings.Contains(errStream.String(), expected) {
+		t.Fatalf("Error is %q, but want %q", errStream.String(), expected)
+	}
+}
+
+func TestReadCommand__page_not_found(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ReadCommand{
+		Meta: *meta,
+	}
+
+	testAPIServer := RunAPIServer()
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "go-scrapbox", "no such page"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodePageNotFound {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodePageNotFound)
+	}
+}
+
+func TestReadCommand__http_failure(t *testing.T) {
+	for _, fixture := range []struct {
+		status   int
+		body     string
+		exitCode ExitCode
+	}{
+		{http.StatusNotFound, `{"name":"NotFoundError","message":"Project not found."}`, ExitCodeProjectNotFound},
+		{http.StatusNotFound, `{"name":"NotFoundError","message":"Page not found."}`, ExitCodePageNotFound},
+		{http.StatusUnauthorized, `{"name":"NotLoggedInError","message":"Log in required."}`, ExitCodeUnauthorized},
+		{http.StatusForbidden, `{"name":"NotMemberError","message":"You are not a member of this project."}`, ExitCodeUnauthorized},
+		{http.StatusTooManyRequests, ``, ExitCodeRateLimited},
+		{http.StatusServiceUnavailable, ``, ExitCodeServerError},
+		{http.StatusBadRequest, ``, ExitCodeFetchFailure},
+	} {
+		outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+		meta := NewTestMeta(outStream, errStream, inStream)
+		command := &ReadCommand{
+			Meta: *meta,
+		}
+
+		testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(fixture.status)
+			w.Write([]byte(fixture.body))
+		}))
+
+		args := []string{"--host", testAPIServer.URL, "--no-cache", "--retries", "0", "go-scrapbox", "http failure"}
+		exitStatus := command.Run(args)
+		testAPIServer.Close()
+
+		if DebugMode {
+			t.Log(outStream.String())
+			t.Log(errStream.String())
+		}
+
+		if ExitCode(exitStatus) != fixture.exitCode {
+			t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), fixture.exitCode)
+		}
+	}
+}
+
+func TestReadCommand__negative_cache(t *testing.T) {
+
+	requests := 0
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"name":"NotFoundError","message":"Page not found."}`))
+	}))
+	defer testAPIServer.Close()
+
+	for _, fixture := range []struct {
+		args     []string
+		requests int
+	}{
+		{[]string{"--no-cache"}, 1},
+		{[]string{"--expire-404", "60"}, 2},
+		{[]string{"--expire-404", "60"}, 2},
+		{[]string{"--no-cache"}, 3},
+	} {
+		outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+		meta := NewTestMeta(outStream, errStream, inStream)
+		command := &ReadCommand{
+			Meta: *meta,
+		}
+
+		args := append([]string{"--host", testAPIServer.URL}, fixture.args...)
+		args = append(args, "go-scrapbox", "negative cache")
+		exitStatus := command.Run(args)
+
+		if DebugMode {
+			t.Log(outStream.String())
+			t.Log(errStream.String())
+		}
+
+		if ExitCode(exitStatus) != ExitCodePageNotFound {
+			t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodePageNotFound)
+		}
+		if requests != fixture.requests {
+			t.Fatalf("Requests are %d after %v, but want %d", requests, fixture.args, fixture.requests)
+		}
+	}
+}
+
+func TestReadCommand__retry_after_throttled(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ReadCommand{
+		Meta: *meta,
+	}
+
+	requests := 0
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"title":"retried","lines":[{"text":"retried"},{"text":"after throttled"}]}`))
+	}))
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "--retries", "1", "--retry-wait", "0", "go-scrapbox", "retried"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+	if requests != 2 {
+		t.Fatalf("Requests are %d, but want %d", requests, 2)
+	}
+
+	expected := "retried\nafter throttled"
+	if !strings.Contains(outStream.String(), expected) {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
+
+func TestReadCommand__give_up_retrying(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ReadCommand{
+		Meta: *meta,
+	}
+
+	requests := 0
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "--retries", "2", "--retry-wait", "0", "go-scrapbox", "gave up"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeServerError {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeServerError)
+	}
+	if requests != 3 {
+		t.Fatalf("Requests are %d, but want %d", requests, 3)
+	}
+}
+
+func TestReadCommand__timeout(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ReadCommand{
+		Meta: *meta,
+	}
+
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "--timeout", "1", "go-scrapbox", "timeout"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeTimeout {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeTimeout)
+	}
+}
+
+func TestReadCommand__offline(t *testing.T) {
+
+	testAPIServer := RunAPIServer()
+	host := testAPIServer.URL
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ReadCommand{
+		Meta: *meta,
+	}
+
+	args := []string{"--host", host, "--expire", "0", "go-scrapbox", "title having paren ( ) mark"}
+	if exitStatus := command.Run(args); ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+	testAPIServer.Close()
+
+	for _, fixture := range []struct {
+		page     string
+		exitCode ExitCode
+	}{
+		{"title having paren ( ) mark", ExitCodeOK},
+		{"title never fetched", ExitCodeNotCached},
+	} {
+		outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+		meta := NewTestMeta(outStream, errStream, inStream)
+		command := &ReadCommand{
+			Meta: *meta,
+		}
+
+		args := []string{"--host", host, "--expire", "0", "--offline", "go-scrapbox", fixture.page}
+		exitStatus := command.Run(args)
+
+		if DebugMode {
+			t.Log(outStream.String())
+			t.Log(errStream.String())
+		}
+
+		if ExitCode(exitStatus) != fixture.exitCode {
+			t.Fatalf("ExitStatus of %q is %s, but want %s", fixture.page, ExitCode(exitStatus), fixture.exitCode)
+		}
+	}
+}
+
+func TestReadCommand__format_markdown(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ReadCommand{
+		Meta: *meta,
+	}
+
+	testAPIServer := RunAPIServer()
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--format", "markdown", "go-scrapbox", "title having paren ( ) mark"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	expected := "# title having paren ( ) mark\n" +
+		"\n" +
+		"[#english](english.md) [#no-url](no-url.md) [#whitespace](whitespace.md) [#no-slash](no-slash.md) [#paren](paren.md) [#no-plus](no-plus.md) [#no-question](no-question.md)\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
+
+func TestReadCommand__format_html(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ReadCommand{
+		Meta: *meta,
+	}
+
+	testAPIServer := RunAPIServer()
+	defer testAPIServer.Close()
+
+	dir, err := ioutil.TempDir("", "scrapbox-template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmplFile := filepath.Join(dir, "page.html")
+	if err := ioutil.WriteFile(tmplFile, []byte("<title>{{.Project}} - {{.Title}}</title>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"--host", testAPIServer.URL, "--format", "html", "--template", tmplFile, "go-scrapbox", "title having paren ( ) mark"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	expected := "<title>go-scrapbox - title having paren ( ) mark</title>\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
+
+func TestReadCommand__format_pretty(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ReadCommand{
+		Meta: *meta,
+	}
+
+	testAPIServer := RunAPIServer()
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--format", "pretty", "go-scrapbox", "title having paren ( ) mark"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	// stdout is not the terminal while testing
+	expected := "title having paren ( ) mark\n"
+	if !strings.HasPrefix(outStream.String(), expected) || strings.Contains(outStream.String(), "\x1b[") {
+		t.Fatalf("Output is %q, but want %q without escape sequences", outStream.String(), expected)
+	}
+}
+
+func TestReadCommand__default_format_without_color(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &ReadCommand{
+		Meta: *meta,
+	}
+
+	reverter := SetTestEnv(EnvNoColor, "1")
+	defer reverter()
+
+	testAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"title":"title","lines":[{"text":"title"},{"text":" item [link] and #tag"}]}`))
+	}))
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "go-scrapbox", "title"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	expected := "title\n• item link and #tag\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
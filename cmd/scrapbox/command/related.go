@@ -0,0 +1,149 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ohtomi/scrapbox/client"
+	"github.com/pkg/errors"
+)
+
+type RelatedCommand struct {
+	Meta
+}
+
+type neighbourRecord struct {
+	Title       string `json:"title"`
+	ProjectName string `json:"projectName,omitempty"`
+	Hops        int    `json:"hops"`
+}
+
+func (r *neighbourRecord) Header() []string {
+	return []string{"title", "projectName", "hops"}
+}
+
+func (r *neighbourRecord) Row() []string {
+	return []string{r.Title, r.ProjectName, strconv.Itoa(r.Hops)}
+}
+
+func (r *neighbourRecord) String() string {
+	return client.Neighbour{Title: r.Title, ProjectName: r.ProjectName}.String()
+}
+
+func (c *RelatedCommand) FetchRelatedPages(ctx context.Context, client *client.Client, project, page string, hops int) ([]client.Neighbour, error) {
+
+	p, err := client.GetPage(ctx, project, page)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get page")
+	}
+
+	return p.Related.Neighbours(hops), nil
+}
+
+func (c *RelatedCommand) Run(args []string) int {
+
+	var (
+		project string
+		page    string
+
+		hops int
+	)
+
+	flags := flag.NewFlagSet("related", flag.ContinueOnError)
+	flags.Usage = func() {
+		c.Ui.Error(c.Help())
+	}
+
+	c.ClientFlags(flags)
+	flags.IntVar(&hops, "hops", 2, "")
+	c.FormatFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return int(ExitCodeParseFlagsError)
+	}
+
+	parsedArgs := flags.Args()
+	if len(parsedArgs) != 2 {
+		c.Ui.Error("you must set PROJECT and PAGE.")
+		return int(ExitCodeBadArgs)
+	}
+	project, page = parsedArgs[0], parsedArgs[1]
+
+	if len(project) == 0 {
+		c.Ui.Error("missing PROJECT.")
+		return int(ExitCodeProjectNotFound)
+	}
+	if len(page) == 0 {
+		c.Ui.Error("missing PAGE.")
+		return int(ExitCodePageNotFound)
+	}
+
+	if hops != 1 && hops != 2 {
+		c.Ui.Error(fmt.Sprintf("hops must be 1 or 2. hops: %d", hops))
+		return int(ExitCodeBadArgs)
+	}
+
+	printer, err := c.NewPrinter()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return int(ExitCodeBadArgs)
+	}
+
+	// process
+
+	client, exitCode := c.NewClient()
+	if exitCode != ExitCodeOK {
+		return int(exitCode)
+	}
+
+	ctx, cancel := NewContext(c.ClientOptions.Timeout)
+	defer cancel()
+
+	relatedPages, err := c.FetchRelatedPages(ctx, client, project, page, hops)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to fetch the scrapbox page. cause: %s", err))
+		return int(ExitCodeOfFetchFailure(err, ExitCodePageNotFound))
+	}
+
+	for _, n := range relatedPages {
+		if err := printer.Print(&neighbourRecord{Title: n.Title, ProjectName: n.ProjectName, Hops: n.Hops}); err != nil {
+			c.Ui.Error(fmt.Sprintf("failed to print the scrapbox page. cause: %s", err))
+			return int(ExitCodeError)
+		}
+	}
+	if err := printer.Flush(); err != nil {
+		c.Ui.Error(fmt.Sprintf("failed to print the scrapbox page. cause: %s", err))
+		return int(ExitCodeError)
+	}
+
+	return int(ExitCodeOK)
+}
+
+func (c *RelatedCommand) Synopsis() string {
+	return "Print page titles related to the scrapbox page"
+}
+
+func (c *RelatedCommand) Help() string {
+	helpText := `usage: scrapbox related [options...] PROJECT PAGE
+
+Options:
+  --token, -t  Scrapbox connect.sid used to access private project.
+  --host, -h   Scrapbox Host. By default, "https://scrapbox.io".
+  --expire     Local Cache Expiration. By default, 3600 seconds.
+  --expire-404 Local Cache Expiration of Missing Pages. By default, 300 seconds.
+  --no-cache   Fetch from Scrapbox regardless of Local Cache.
+  --ua         User Agent. By default, "ScrapboxGoClient/x.x.x"
+  --retries    Retry Count on 429, 5xx and Network Failure. By default, 2.
+  --retry-wait Retry Wait, doubled on every retry. By default, 1 second.
+  --rate-limit Requests per Second, optionally with Burst as RATE:BURST. By default, unlimited.
+  --timeout    Timeout in seconds. By default, 0 (no timeout).
+  --offline    Serve Local Cache regardless of Expiration, never accessing Scrapbox.
+  --stale-if-error Serve Local Cache Expired within the seconds on Failure. By default, 0 (never).
+  --hops       Link distance, 1 or 2. By default, 2.
+  --format     Output Format, one of text, json, jsonl, tsv and csv. By default, text.
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,104 @@
+package command
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+)
+
+func RunRelatedPagesAPIServer() *httptest.Server {
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(heredoc.Doc(`
+			{
+			  "id": "1",
+			  "title": "center",
+			  "lines": [{"id": "1", "text": "center"}, {"id": "2", "text": "[foo] [bar]"}],
+			  "links": ["foo", "bar"],
+			  "relatedPages": {
+			    "links1hop": [{"id": "2", "title": "foo"}, {"id": "3", "title": "bar"}],
+			    "links2hop": [{"id": "4", "title": "baz", "linksLc": ["foo"]}, {"id": "2", "title": "foo", "linksLc": ["bar"]}],
+			    "projectLinks1hop": [{"id": "5", "title": "qux", "projectName": "other"}]
+			  }
+			}
+		`)))
+	}))
+}
+
+func TestRelatedCommand__print_two_hops(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &RelatedCommand{
+		Meta: *meta,
+	}
+
+	testAPIServer := RunRelatedPagesAPIServer()
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "go-scrapbox", "center"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	expected := "foo\nbar\n/other/qux\nbaz\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
+
+func TestRelatedCommand__print_one_hop(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &RelatedCommand{
+		Meta: *meta,
+	}
+
+	testAPIServer := RunRelatedPagesAPIServer()
+	defer testAPIServer.Close()
+
+	args := []string{"--host", testAPIServer.URL, "--expire", "0", "--hops", "1", "go-scrapbox", "center"}
+	exitStatus := command.Run(args)
+
+	if DebugMode {
+		t.Log(outStream.String())
+		t.Log(errStream.String())
+	}
+
+	if ExitCode(exitStatus) != ExitCodeOK {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeOK)
+	}
+
+	expected := "foo\nbar\n/other/qux\n"
+	if outStream.String() != expected {
+		t.Fatalf("Output is %q, but want %q", outStream.String(), expected)
+	}
+}
+
+func TestRelatedCommand__bad_hops(t *testing.T) {
+
+	outStream, errStream, inStream := new(bytes.Buffer), new(bytes.Buffer), strings.NewReader("")
+	meta := NewTestMeta(outStream, errStream, inStream)
+	command := &RelatedCommand{
+		Meta: *meta,
+	}
+
+	args := []string{"--hops", "3", "go-scrapbox", "center"}
+	exitStatus := command.Run(args)
+
+	if ExitCode(exitStatus) != ExitCodeBadArgs {
+		t.Fatalf("ExitStatus is %s, but want %s", ExitCode(exitStatus), ExitCodeBadArgs)
+	}
+}
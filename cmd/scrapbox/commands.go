@@ -22,11 +22,46 @@ func Commands(meta *command.Meta) map[string]cli.CommandFactory {
 				Meta: *meta,
 			}, nil
 		},
+		"related": func() (cli.Command, error) {
+			return &command.RelatedCommand{
+				Meta: *meta,
+			}, nil
+		},
 		"open": func() (cli.Command, error) {
 			return &command.OpenCommand{
 				Meta: *meta,
 			}, nil
 		},
+		"cache": func() (cli.Command, error) {
+			return &command.CacheCommand{
+				Meta: *meta,
+			}, nil
+		},
+		"cache ls": func() (cli.Command, error) {
+			return &command.CacheListCommand{
+				Meta: *meta,
+			}, nil
+		},
+		"cache stat": func() (cli.Command, error) {
+			return &command.CacheStatCommand{
+				Meta: *meta,
+			}, nil
+		},
+		"cache purge": func() (cli.Command, error) {
+			return &command.CachePurgeCommand{
+				Meta: *meta,
+			}, nil
+		},
+		"cache gc": func() (cli.Command, error) {
+			return &command.CacheGCCommand{
+				Meta: *meta,
+			}, nil
+		},
+		"cache migrate": func() (cli.Command, error) {
+			return &command.CacheMigrateCommand{
+				Meta: *meta,
+			}, nil
+		},
 
 		"version": func() (cli.Command, error) {
 			return &command.VersionCommand{